@@ -0,0 +1,102 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAsyncPollInterval = time.Second
+	defaultAsyncMaxPolls     = 30
+	defaultAsyncMaxWait      = time.Minute
+)
+
+// AsyncPollingConfig describes how accepted asynchronous operations are polled until they complete
+type AsyncPollingConfig struct {
+	// Interval between polls when the response has no Retry-After header, defaults to 1s
+	Interval time.Duration
+	// MaxPolls bounds the polls per call, defaults to 30
+	MaxPolls int
+	// MaxWait caps the delay a Retry-After header asks for between polls, defaults to 1m
+	MaxWait time.Duration
+}
+
+// pollAsync polls the status URL of a 202 Accepted response until the operation completes,
+// returning the last response when it is still running after the maximum number of polls
+func (c *Converter) pollAsync(ctx context.Context, client *http.Client, req *http.Request, resp *http.Response) (*http.Response, error) {
+	config := c.options.AsyncPolling
+	interval := config.Interval
+	if interval <= 0 {
+		interval = defaultAsyncPollInterval
+	}
+	maxPolls := config.MaxPolls
+	if maxPolls <= 0 {
+		maxPolls = defaultAsyncMaxPolls
+	}
+	maxWait := config.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultAsyncMaxWait
+	}
+
+	// Status responses usually omit the status URL, it is kept from the previous responses
+	var statusURL *url.URL
+	for polls := 0; polls < maxPolls && resp.StatusCode == http.StatusAccepted; polls++ {
+		location := resp.Header.Get("Operation-Location")
+		if location == "" {
+			location = resp.Header.Get("Location")
+		}
+		if location != "" {
+			ref, err := url.Parse(location)
+			if err != nil {
+				return resp, nil
+			}
+			statusURL = resp.Request.URL.ResolveReference(ref)
+		}
+		if statusURL == nil {
+			return resp, nil
+		}
+		wait := retryAfter(resp.Header, interval, maxWait)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		pollReq, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create poll request: %w", err)
+		}
+		pollReq.Header = req.Header.Clone()
+		// The Host override is kept for polls on the same host, stripCredentials drops it for other hosts
+		pollReq.Host = req.Host
+		for _, key := range []string{"Content-Type", "Content-Encoding", "Prefer", "X-HTTP-Method-Override"} {
+			pollReq.Header.Del(key)
+		}
+		stripCredentials(pollReq, req.URL)
+		resp, err = client.Do(pollReq)
+		if err != nil {
+			return nil, fmt.Errorf("poll request failed: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// retryAfter returns the delay of a Retry-After header in seconds capped at maxWait, or the fallback
+func retryAfter(header http.Header, fallback, maxWait time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return fallback
+	}
+	if seconds > int(maxWait/time.Second) {
+		return maxWait
+	}
+	return time.Duration(seconds) * time.Second
+}
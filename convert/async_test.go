@@ -0,0 +1,60 @@
+package convert
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Second},
+		{value: "soon", want: time.Second},
+		{value: "-1", want: time.Second},
+		{value: "0", want: 0},
+		{value: "5", want: 5 * time.Second},
+		{value: "60", want: time.Minute},
+		{value: "3600", want: time.Minute},
+		{value: "9223372036854775807", want: time.Minute},
+	}
+	for _, tt := range tests {
+		header := http.Header{"Retry-After": {tt.value}}
+		if got := retryAfter(header, time.Second, time.Minute); got != tt.want {
+			t.Errorf("Retry-After %q: got %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAsyncPolling(t *testing.T) {
+	var pollHost string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status" {
+			pollHost = r.Host
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"done":true}`)
+			return
+		}
+		w.Header().Set("Location", "/status")
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer upstream.Close()
+
+	_, s := convertSpec(t, crossHostSpec, Options{AsyncPolling: &AsyncPollingConfig{MaxWait: 10 * time.Millisecond}})
+	start := time.Now()
+	text, ok := callTool(t, s, "listItems", crossHostArgs(upstream.URL))
+	if !ok {
+		t.Fatal(text)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("polling took %v, want the Retry-After delay capped at MaxWait", elapsed)
+	}
+	if pollHost != "api.example.com" {
+		t.Errorf("poll Host = %q, want the Host override kept on the same host", pollHost)
+	}
+}
@@ -0,0 +1,31 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// openBodyFile opens a request body file, refusing paths that escape the root directory
+func openBodyFile(root, name string) (io.ReadCloser, int64, error) {
+	dir, err := os.OpenRoot(root)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open body file root: %w", err)
+	}
+	defer dir.Close()
+
+	file, err := dir.Open(name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open body file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to stat body file: %w", err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, 0, fmt.Errorf("body file %s is a directory", name)
+	}
+	return file, info.Size(), nil
+}
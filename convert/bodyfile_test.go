@@ -0,0 +1,70 @@
+package convert
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenBodyFile(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside.txt")
+	for _, name := range []string{filepath.Join(root, "sub", "a.txt"), outside} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../outside.txt", filepath.Join(root, "relative-escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub/a.txt", filepath.Join(root, "inner.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "sub/a.txt"},
+		{name: "sub/../sub/a.txt"},
+		{name: "inner.txt"},
+		{name: "../outside.txt", wantErr: true},
+		{name: "sub/../../outside.txt", wantErr: true},
+		{name: outside, wantErr: true},
+		{name: "escape.txt", wantErr: true},
+		{name: "relative-escape.txt", wantErr: true},
+		{name: "sub", wantErr: true},
+		{name: "missing.txt", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, size, err := openBodyFile(root, tt.name)
+			if tt.wantErr {
+				if err == nil {
+					file.Close()
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			data, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "content" || size != int64(len(data)) {
+				t.Errorf("read %q of size %d, want content of size 7", data, size)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// bundleRootNames are the file names looked up as the root document of a bundle
+var bundleRootNames = []string{
+	"openapi.yaml",
+	"openapi.yml",
+	"openapi.json",
+	"swagger.yaml",
+	"swagger.yml",
+	"swagger.json",
+}
+
+// ParseBundle parses a multi-file OpenAPI document from a directory or a zip archive,
+// references to other files are resolved from within the bundle
+func (p *Parser) ParseBundle(bundlePath string) error {
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to read OpenAPI bundle: %w", err)
+	}
+
+	if info.IsDir() {
+		return p.parseFS(os.DirFS(bundlePath))
+	}
+
+	archive, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to open OpenAPI bundle: %w", err)
+	}
+	defer archive.Close()
+
+	return p.parseFS(archive)
+}
+
+func (p *Parser) parseFS(fsys fs.FS) error {
+	root, err := findBundleRoot(fsys)
+	if err != nil {
+		return err
+	}
+
+	data, err := fs.ReadFile(fsys, root)
+	if err != nil {
+		return fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(_ *openapi3.Loader, location *url.URL) ([]byte, error) {
+		if location.Scheme != "" || location.Host != "" {
+			return nil, openapi3.ErrURINotSupported
+		}
+		return fs.ReadFile(fsys, path.Clean(strings.TrimPrefix(location.Path, "/")))
+	}
+
+	doc, err := loader.LoadFromDataWithPath(data, &url.URL{Path: root})
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+
+	p.doc = doc
+	return nil
+}
+
+// findBundleRoot returns the shallowest root document in the bundle
+func findBundleRoot(fsys fs.FS) (string, error) {
+	var root string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		for _, rootName := range bundleRootNames {
+			if path.Base(name) != rootName {
+				continue
+			}
+			if root == "" || strings.Count(name, "/") < strings.Count(root, "/") {
+				root = name
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read OpenAPI bundle: %w", err)
+	}
+	if root == "" {
+		return "", errors.New("no root OpenAPI document found in bundle")
+	}
+	return root, nil
+}
@@ -0,0 +1,117 @@
+package convert
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedResponses bounds the memory used by cached responses
+const maxCachedResponses = 100
+
+// cachedResponse is a response kept by the response cache
+type cachedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+// responseCache keeps successful GET responses for a fixed time to live
+type responseCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	responses map[string]cachedResponse
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:       ttl,
+		responses: make(map[string]cachedResponse),
+	}
+}
+
+// responseCacheKey identifies a request by its method, URL and headers
+func responseCacheKey(req *http.Request) string {
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.String())
+	for _, key := range keys {
+		b.WriteByte('\n')
+		b.WriteString(key)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(req.Header[key], ","))
+	}
+	return b.String()
+}
+
+// get returns the unexpired response cached under the key
+func (c *responseCache) get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	response, ok := c.responses[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	if time.Now().After(response.expires) {
+		delete(c.responses, key)
+		return cachedResponse{}, false
+	}
+	return response, true
+}
+
+// set caches a response unless its Cache-Control forbids storing it,
+// evicting expired responses and then the one expiring first when full
+func (c *responseCache) set(key string, statusCode int, header http.Header, body []byte) {
+	if hasCacheDirective(header, "no-store") {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := c.responses[key]; !ok && len(c.responses) >= maxCachedResponses {
+		var oldest string
+		for k, response := range c.responses {
+			if now.After(response.expires) {
+				delete(c.responses, k)
+				continue
+			}
+			if oldest == "" || response.expires.Before(c.responses[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.responses) >= maxCachedResponses {
+			delete(c.responses, oldest)
+		}
+	}
+	c.responses[key] = cachedResponse{
+		statusCode: statusCode,
+		header:     header,
+		body:       body,
+		expires:    now.Add(c.ttl),
+	}
+}
+
+// hasCacheDirective reports whether the Cache-Control header contains the directive
+func hasCacheDirective(header http.Header, directive string) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(d), directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
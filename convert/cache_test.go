@@ -0,0 +1,104 @@
+package convert
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const cacheSpec = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    key: {type: apiKey, in: query, name: api_key}
+    session: {type: apiKey, in: cookie, name: session}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      security: [{bearer: []}, {key: []}, {session: []}]
+      parameters:
+        - {name: page, in: query, schema: {type: string}}
+      responses: {"200": {description: ok}}
+`
+
+// newCountingUpstream starts a server counting its requests, answering with the given Cache-Control
+func newCountingUpstream(t *testing.T, cacheControl string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream, &hits
+}
+
+func TestResponseCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		second   map[string]any
+		wantHits int32
+	}{
+		{name: "identical call hits", second: map[string]any{"openapi|auth_token": "a"}, wantHits: 1},
+		{name: "other bearer token misses", second: map[string]any{"openapi|auth_token": "b"}, wantHits: 2},
+		{name: "other query API key misses", second: map[string]any{"openapi|auth_token": "a", "openapi|auth_key": "k"}, wantHits: 2},
+		{name: "other cookie API key misses", second: map[string]any{"openapi|auth_token": "a", "openapi|auth_session": "s"}, wantHits: 2},
+		{name: "other parameter misses", second: map[string]any{"openapi|auth_token": "a", "query|page": "2"}, wantHits: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, hits := newCountingUpstream(t, "")
+			_, s := convertSpec(t, cacheSpec, Options{CacheTTL: time.Minute})
+			for _, args := range []map[string]any{{"openapi|auth_token": "a"}, tt.second} {
+				args["openapi|server_addr"] = upstream.URL
+				if text, ok := callTool(t, s, "listItems", args); !ok {
+					t.Fatal(text)
+				}
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("upstream hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestResponseCacheNoStore(t *testing.T) {
+	for _, cacheControl := range []string{"no-store", "private, No-Store"} {
+		upstream, hits := newCountingUpstream(t, cacheControl)
+		_, s := convertSpec(t, cacheSpec, Options{CacheTTL: time.Minute})
+		for range 2 {
+			if text, ok := callTool(t, s, "listItems", map[string]any{"openapi|server_addr": upstream.URL, "openapi|auth_token": "a"}); !ok {
+				t.Fatal(text)
+			}
+		}
+		if got := hits.Load(); got != 2 {
+			t.Errorf("Cache-Control %q: upstream hits = %d, want 2", cacheControl, got)
+		}
+	}
+}
+
+func TestResponseCacheExpires(t *testing.T) {
+	cache := newResponseCache(time.Minute)
+	cache.set("key", http.StatusOK, http.Header{}, []byte("body"))
+	if response, ok := cache.get("key"); !ok || string(response.body) != "body" {
+		t.Fatalf("get = %v, %v, want the cached body", response, ok)
+	}
+
+	cache.responses["key"] = cachedResponse{expires: time.Now().Add(-time.Second)}
+	if _, ok := cache.get("key"); ok {
+		t.Error("expired response was returned")
+	}
+	if _, ok := cache.responses["key"]; ok {
+		t.Error("expired response wasn't evicted")
+	}
+}
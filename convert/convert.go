@@ -7,11 +7,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"maps"
+	"mime"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -20,31 +28,247 @@ type Options struct {
 	ServerName     string
 	Version        string
 	ToolNamePrefix string
+	ToolNameSuffix string
+	// IncludeMethodInName prefixes operation IDs with the lowercase HTTP method in tool names
+	IncludeMethodInName bool
+	// VersionInName prefixes tool names with the API version named by the operation path or,
+	// failing that, the document version, so tools of several versions can be served together
+	VersionInName bool
+	// Instructions overrides the server instructions derived from the document info
+	Instructions string
+	// AllowMethods restricts the converted operations to these HTTP methods
+	AllowMethods []string
+	// IncludeOperationIDs restricts the converted operations to these operation IDs
+	IncludeOperationIDs []string
+	// SkipInvalidOperations skips operations that fail to convert instead of aborting
+	SkipInvalidOperations bool
+	// IdempotencyKeyHeader is the header carrying a generated idempotency key on POST requests
+	IdempotencyKeyHeader string
+	// ResponseCodes limits the described responses, supporting classes like "2XX"
+	ResponseCodes []string
+	// ResponseAsResource stores response bodies as MCP resources and returns a reference to them
+	ResponseAsResource bool
+	// IncludeAPIContext prepends a short preamble about the API to each tool description
+	IncludeAPIContext bool
+	// PrettyBody indents JSON request bodies instead of sending them compact
+	PrettyBody bool
+	// PathPrefix is prepended to every operation path, for gateways mounting the API under a prefix
+	PathPrefix string
+	// DefaultHeaders are sent with every request unless overridden by header arguments
+	DefaultHeaders map[string]string
+	// DefaultQuery parameters are sent with every request unless overridden by query arguments
+	DefaultQuery map[string]string
+	// AcceptLanguage is the default Accept-Language header, overridable per call
+	AcceptLanguage string
+	// Transport is used to send upstream requests, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// TransportConfig tunes the default transport, ignored when Transport is set
+	TransportConfig *TransportConfig
+	// MaxRedirects bounds the redirects followed per request, defaults to 10, negative disables redirects
+	MaxRedirects int
+	// RawResponseBody returns only the body of successful responses, without the status code prefix
+	RawResponseBody bool
+	// JSONContentTypes are response content types treated as JSON in addition to
+	// application/json and +json suffixed types, e.g. text/plain for APIs mislabeling JSON
+	JSONContentTypes []string
+	// SplitResponseContent returns the status line and the response body as separate content blocks
+	SplitResponseContent bool
+	// Middlewares wrap every generated tool handler, the first one being the outermost
+	Middlewares []func(server.ToolHandlerFunc) server.ToolHandlerFunc
+	// CacheTTL caches successful GET responses for this duration, zero disables caching
+	CacheTTL time.Duration
+	// BaseURL resolves relative server URLs, defaults to the URL the document was loaded from
+	BaseURL string
+	// IncludeRefNames names the referenced component in the description of schemas using $ref
+	IncludeRefNames bool
+	// LogRequests logs every upstream request, redacting credentials and fields with format password
+	LogRequests bool
+	// BodyFileRoot enables streaming request bodies from local files, which must reside under this directory
+	BodyFileRoot string
+	// MethodOverride sends verbs other than GET and POST as POST with an X-HTTP-Method-Override header
+	MethodOverride bool
+	// MockMode returns the documented example of the first successful response instead of calling the API
+	MockMode bool
+	// RequestTimeout bounds each upstream call, a sooner deadline on the call context still applies
+	RequestTimeout time.Duration
+	// RequireResponseContentTypes restricts the converted operations to those documenting
+	// a successful response with one of these content types
+	RequireResponseContentTypes []string
+	// ToolListChanged advertises the listChanged tool capability, clients are notified when Reload swaps the tools
+	ToolListChanged bool
+	// Pagination follows the next pages of successful GET responses and aggregates them into one result
+	Pagination *PaginationConfig
+	// QueryBoolFormat encodes boolean query parameters as BoolFormatLiteral, BoolFormatNumeric
+	// or BoolFormatPresence, defaults to BoolFormatLiteral
+	QueryBoolFormat string
+	// AllowedServers restricts the server addresses offered to and accepted from callers
+	AllowedServers []string
+	// FlattenAllOf merges the branches of allOf schemas into a single schema instead of listing them
+	FlattenAllOf bool
+	// AuthTokenFile is a file holding a bearer token sent when the caller supplies no credentials,
+	// it is read again every few seconds so rotated tokens are picked up
+	AuthTokenFile string
+	// OAuth2RefreshToken is exchanged for bearer access tokens sent when the caller supplies no credentials,
+	// they are refreshed once expired. OAuth2TokenURL defaults to the token URL of the authorization code
+	// flow declared by the document, OAuth2ClientID and OAuth2ClientSecret authenticate the client.
+	OAuth2RefreshToken string
+	OAuth2ClientID     string
+	OAuth2ClientSecret string
+	OAuth2TokenURL     string
+	// GzipMinBodySize gzip-compresses request bodies of at least this many bytes, zero disables compression.
+	// Operations may enable or disable compression with x-mcp-gzip.
+	GzipMinBodySize int
+	// AsyncPolling sends Prefer: respond-async and polls the status URL of 202 Accepted responses
+	// until the operation completes, returning the final response
+	AsyncPolling *AsyncPollingConfig
+	// CSVSummary replaces large successful text/csv response bodies with their header, row count and first rows
+	CSVSummary *CSVSummaryConfig
+	// AuditMode appends a record of the upstream request and response, without secrets, to each tool result
+	AuditMode bool
+	// OnResponse is called after each upstream request completes with the operation ID, the final
+	// status code, the time taken and the error failing the call, status is zero when no response was received
+	OnResponse func(operationID string, status int, duration time.Duration, err error)
+	// MaxSchemaDepth bounds the nesting of converted schemas, deeper schemas are replaced by a truncation
+	// marker accepting any value of their type, zero leaves the depth unbounded. Set it to convert
+	// documents with recursive schemas, whose expansion otherwise never ends
+	MaxSchemaDepth int
+}
+
+// OperationInfo describes the OpenAPI operation a tool was generated from
+type OperationInfo struct {
+	OperationID string
+	Method      string
+	Path        string
+	Tags        []string
+	// Version is the API version named by the path or, failing that, the document version
+	Version string
+	// RequiresConfirmation reports whether the call should be approved by a human first
+	RequiresConfirmation bool
+}
+
+func (o OperationInfo) String() string {
+	if o.OperationID == "" {
+		return fmt.Sprintf("Operation: %s %s", o.Method, o.Path)
+	}
+	return fmt.Sprintf("Operation: %s %s (operationId: %s)", o.Method, o.Path, o.OperationID)
 }
 
 // Converter represents an OpenAPI to MCP converter
 type Converter struct {
-	parser  *Parser
-	options Options
+	// mu guards the document and the results of the last conversion, which Reload replaces
+	mu         sync.RWMutex
+	parser     *Parser
+	options    Options
+	server     *server.MCPServer
+	operations map[string]OperationInfo
+	skipped    []*OperationConvertError
+	tools      []mcp.Tool
+	filtered   int
+	responses  *responseStore
+	cache      *responseCache
+	tokenFile  *tokenFile
+	oauth2     *oauth2Token
+	warnings   []ConversionWarning
+	// current is the operation being converted, used to attribute warnings
+	current OperationInfo
+	// inRequestBody is set while converting a request body schema, whose readOnly properties are excluded
+	inRequestBody bool
+	// schemaDepth is the nesting depth of the schema being processed, bounded by MaxSchemaDepth
+	schemaDepth int
 }
 
 // NewConverter creates a new OpenAPI to MCP converter
 func NewConverter(parser *Parser, options Options) *Converter {
 	return &Converter{
-		parser:  parser,
-		options: options,
+		parser:     parser,
+		options:    options,
+		operations: make(map[string]OperationInfo),
 	}
 }
 
+// Operation returns the source operation of a converted tool
+func (c *Converter) Operation(toolName string) (OperationInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	info, ok := c.operations[toolName]
+	return info, ok
+}
+
+// Tools returns the tools generated by the last conversion in registration order
+func (c *Converter) Tools() []mcp.Tool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tools
+}
+
 // Convert converts an OpenAPI document to an MCP configuration
 func (c *Converter) Convert() (*server.MCPServer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.prepare(); err != nil {
+		return nil, err
+	}
+
+	// Create the MCP configuration
+	mcpServer := c.newMCPServer(c.options.ServerName)
+
+	tools, err := c.convertTools()
+	if err != nil {
+		return nil, err
+	}
+	mcpServer.AddTools(tools...)
+	c.server = mcpServer
+
+	return mcpServer, nil
+}
+
+// untaggedServer is the key of the server holding the tools of operations without tags
+const untaggedServer = "default"
+
+// ConvertByTag converts an OpenAPI document to one MCP server per tag, keyed by tag,
+// so huge APIs can be mounted as several namespaces. Operations are grouped by their
+// first tag, untagged ones are served by the "default" server. Reload isn't supported.
+func (c *Converter) ConvertByTag() (map[string]*server.MCPServer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.prepare(); err != nil {
+		return nil, err
+	}
+
+	tools, err := c.convertTools()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make(map[string]*server.MCPServer)
+	for _, tool := range tools {
+		tag := untaggedServer
+		if tags := c.operations[tool.Tool.Name].Tags; len(tags) > 0 {
+			tag = tags[0]
+		}
+		mcpServer, ok := servers[tag]
+		if !ok {
+			mcpServer = c.newMCPServer(c.options.ServerName + " - " + tag)
+			servers[tag] = mcpServer
+		}
+		mcpServer.AddTool(tool.Tool, tool.Handler)
+	}
+	c.server = nil
+
+	return servers, nil
+}
+
+// prepare checks the document and sets up the state shared by the tools of a conversion
+func (c *Converter) prepare() error {
 	if c.parser.GetDocument() == nil {
-		return nil, errors.New("no OpenAPI document loaded")
+		return ErrNoDocument
 	}
 
 	info := c.parser.GetInfo()
 	if info == nil {
-		return nil, errors.New("no info found in OpenAPI document")
+		return ErrNoInfo
 	}
 
 	if c.options.ServerName == "" {
@@ -53,58 +277,444 @@ func (c *Converter) Convert() (*server.MCPServer, error) {
 	if c.options.Version == "" {
 		c.options.Version = info.Version
 	}
+	if c.options.Instructions == "" {
+		c.options.Instructions = getInstructions(info)
+	}
 
-	// Create the MCP configuration
+	c.responses = nil
+	if c.options.ResponseAsResource {
+		c.responses = newResponseStore()
+	}
+	c.cache = nil
+	if c.options.CacheTTL > 0 {
+		c.cache = newResponseCache(c.options.CacheTTL)
+	}
+	c.tokenFile = nil
+	if c.options.AuthTokenFile != "" {
+		c.tokenFile = newTokenFile(c.options.AuthTokenFile)
+	}
+	if c.options.OAuth2RefreshToken != "" {
+		tokenURL := c.options.OAuth2TokenURL
+		if tokenURL == "" {
+			tokenURL = c.oauth2TokenURL()
+		}
+		if tokenURL == "" {
+			return errors.New("OAuth2TokenURL is required, the document declares no authorization code token URL")
+		}
+		// Reloads keep the tokens, the refresh token may have been rotated since
+		if c.oauth2 == nil || c.oauth2.tokenURL != tokenURL {
+			c.oauth2 = newOAuth2Token(tokenURL, c.options.OAuth2ClientID, c.options.OAuth2ClientSecret, c.options.OAuth2RefreshToken)
+		}
+	}
+	return nil
+}
+
+// newMCPServer creates an MCP server without tools
+func (c *Converter) newMCPServer(name string) *server.MCPServer {
+	serverOptions := []server.ServerOption{server.WithInstructions(c.options.Instructions)}
+	if c.options.ToolListChanged {
+		serverOptions = append(serverOptions, server.WithToolCapabilities(true))
+	}
 	mcpServer := server.NewMCPServer(
-		c.options.ServerName,
+		name,
 		c.options.Version,
+		serverOptions...,
 	)
+	if c.responses != nil {
+		mcpServer.AddResourceTemplate(c.responses.resourceTemplate(), c.responses.read)
+	}
+	return mcpServer
+}
 
-	servers := c.parser.GetServers()
-	var server *openapi3.Server
-	if len(servers) == 1 {
-		server = servers[0]
+// Reload converts the document of parser and replaces the tools of the server
+// returned by Convert, notifying clients that the tool list changed.
+// The previous tools are kept when the conversion fails.
+func (c *Converter) Reload(parser *Parser) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.server == nil {
+		return errors.New("convert must be called before reload")
+	}
+	if parser.GetDocument() == nil {
+		return ErrNoDocument
 	}
 
-	// Process each path and operation
-	for path, pathItem := range c.parser.GetPaths().Map() {
+	// Handlers of the previous tools capture the document state they need and never read
+	// the fields replaced here, so calls in flight are unaffected
+	previousParser, operations, skipped, previousTools, warnings, filtered := c.parser, c.operations, c.skipped, c.tools, c.warnings, c.filtered
+	c.parser = parser
+	tools, err := c.convertTools()
+	if err != nil {
+		c.parser, c.operations, c.skipped, c.tools, c.warnings, c.filtered = previousParser, operations, skipped, previousTools, warnings, filtered
+		return err
+	}
+	c.server.SetTools(tools...)
+	return nil
+}
+
+// convertTools converts every allowed operation of the document to a tool with its handler
+func (c *Converter) convertTools() ([]server.ServerTool, error) {
+	c.operations = make(map[string]OperationInfo)
+	c.skipped = nil
+	c.tools = nil
+	c.warnings = nil
+	c.filtered = 0
+
+	client := c.newHTTPClient()
+
+	var tools []server.ServerTool
+	// Process each path and operation in a stable order
+	paths := c.parser.GetPaths().Map()
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathItem := paths[path]
 		operations := getOperations(pathItem)
-		for method, operation := range operations {
-			tool, err := c.convertOperation(path, method, operation)
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+			if !c.methodAllowed(method) || !c.operationIncluded(path, method, operation) || !c.responseContentTypeAllowed(operation) {
+				c.filtered++
+				continue
+			}
+
+			parameters := c.uniqueParameters(path, method, mergeParameters(pathItem.Parameters, operation.Parameters))
+			servers, apiServer := c.operationServers(pathItem, operation)
+			tool, info, err := c.convertOperation(path, method, operation, parameters, servers, apiServer)
 			if err != nil {
-				return nil, fmt.Errorf("failed to convert operation %s %s: %w", method, path, err)
+				err = c.skipOperation(&OperationConvertError{Path: path, Method: method, Err: err})
+				if err != nil {
+					return nil, err
+				}
+				continue
 			}
 
-			handler, err := newHandler(server, path, method, operation)
+			handler, err := c.newHandler(client, apiServer, path, method, operation, parameters)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create handler for operation %s %s: %w", method, path, err)
+				err = c.skipOperation(&OperationConvertError{
+					Path:   path,
+					Method: method,
+					Err:    fmt.Errorf("failed to create handler: %w", err),
+				})
+				if err != nil {
+					return nil, err
+				}
+				continue
 			}
 
-			mcpServer.AddTool(*tool, handler)
+			// Only operations served by a tool are recorded
+			c.operations[tool.Name] = info
+			tools = append(tools, server.ServerTool{Tool: *tool, Handler: c.applyMiddlewares(handler)})
+			c.tools = append(c.tools, *tool)
 		}
 	}
 
-	return mcpServer, nil
+	return tools, nil
 }
 
-func newHandler(server *openapi3.Server, path, method string, operation *openapi3.Operation) (server.ToolHandlerFunc, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		arg := getArgs(request.Params.Arguments)
+// applyMiddlewares wraps the handler with the configured middlewares in order
+func (c *Converter) applyMiddlewares(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	for i := len(c.options.Middlewares) - 1; i >= 0; i-- {
+		handler = c.options.Middlewares[i](handler)
+	}
+	return handler
+}
+
+// methodAllowed reports whether operations with the method should be converted
+func (c *Converter) methodAllowed(method string) bool {
+	if len(c.options.AllowMethods) == 0 {
+		return true
+	}
+	for _, allowed := range c.options.AllowMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// operationIncluded reports whether the operation is selected by its operation ID
+func (c *Converter) operationIncluded(path, method string, operation *openapi3.Operation) bool {
+	if len(c.options.IncludeOperationIDs) == 0 {
+		return true
+	}
+	return slices.Contains(c.options.IncludeOperationIDs, c.parser.GetOperationID(path, method, operation))
+}
+
+// responseContentTypeAllowed reports whether a successful response of the operation
+// has one of the required content types, ignoring media type parameters
+func (c *Converter) responseContentTypeAllowed(operation *openapi3.Operation) bool {
+	if len(c.options.RequireResponseContentTypes) == 0 {
+		return true
+	}
+	for _, mediaType := range successMediaTypes(operation) {
+		for _, required := range c.options.RequireResponseContentTypes {
+			if strings.EqualFold(mediaType, required) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// successMediaTypes returns the media types of the successful responses of an operation, without parameters
+func successMediaTypes(operation *openapi3.Operation) []string {
+	if operation.Responses == nil {
+		return nil
+	}
+	var mediaTypes []string
+	for code, responseRef := range operation.Responses.Map() {
+		if !matchResponseCode(code, []string{"2XX"}) || responseRef.Value == nil {
+			continue
+		}
+		for contentType := range responseRef.Value.Content {
+			mediaType, _, err := mime.ParseMediaType(contentType)
+			if err != nil {
+				continue
+			}
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	return mediaTypes
+}
+
+// skipOperation records an operation that failed to convert,
+// the error is returned unchanged when skipping is disabled
+func (c *Converter) skipOperation(err *OperationConvertError) error {
+	if !c.options.SkipInvalidOperations {
+		return err
+	}
+	log.Printf("skipping operation: %v", err)
+	c.skipped = append(c.skipped, err)
+	return nil
+}
+
+// Skipped returns the operations skipped by the last conversion
+func (c *Converter) Skipped() []*OperationConvertError {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.skipped
+}
+
+const defaultMaxRedirects = 10
+
+// newHTTPClient creates the client shared by all handlers
+func (c *Converter) newHTTPClient() *http.Client {
+	transport := c.options.Transport
+	if transport == nil && c.options.TransportConfig != nil {
+		transport = c.options.TransportConfig.newTransport()
+	}
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: c.checkRedirect,
+	}
+}
+
+// TransportConfig tunes the transport sending upstream requests
+type TransportConfig struct {
+	// ForceHTTP2 only speaks HTTP/2, negotiated over TLS and with prior knowledge over plain connections,
+	// requests to servers without HTTP/2 support fail instead of falling back to HTTP/1.1
+	ForceHTTP2 bool
+	// MaxIdleConns bounds the idle connections across all hosts
+	MaxIdleConns int
+	// MaxIdleConnsPerHost bounds the idle connections kept per host
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes idle connections after this duration
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives uses a new connection for every request
+	DisableKeepAlives bool
+}
+
+// newTransport builds a transport from http.DefaultTransport, zero fields keep its defaults
+func (t *TransportConfig) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t.ForceHTTP2 {
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+	if t.MaxIdleConns > 0 {
+		transport.MaxIdleConns = t.MaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+	}
+	if t.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = t.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = t.DisableKeepAlives
+	return transport
+}
+
+// checkRedirect bounds redirect chains and strips credentials when a redirect leaves the original host
+func (c *Converter) checkRedirect(req *http.Request, via []*http.Request) error {
+	maxRedirects := c.options.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	if maxRedirects < 0 {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	stripCredentials(req, via[0].URL)
+	return nil
+}
+
+// credentialHeadersKey is the context key of the headers carrying the credentials of an upstream request
+type credentialHeadersKey struct{}
+
+// credentialParamsKey is the context key of the query parameters carrying the API keys of an upstream request
+type credentialParamsKey struct{}
+
+// credentialParams returns the query parameters carrying API keys for the security requirements
+func (s securitySchemes) credentialParams(security openapi3.SecurityRequirements) []string {
+	var params []string
+	schemes := s.apiKeySchemes(security)
+	for _, schemeName := range slices.Sorted(maps.Keys(schemes)) {
+		if scheme := schemes[schemeName]; scheme.In == openapi3.ParameterInQuery {
+			params = append(params, scheme.Name)
+		}
+	}
+	return params
+}
+
+// credentialHeaders returns the headers carrying credentials for the security requirements
+func (s securitySchemes) credentialHeaders(security openapi3.SecurityRequirements) []string {
+	headers := []string{"Authorization", "Proxy-Authorization", "Cookie"}
+	schemes := s.apiKeySchemes(security)
+	for _, schemeName := range slices.Sorted(maps.Keys(schemes)) {
+		if scheme := schemes[schemeName]; scheme.In == openapi3.ParameterInHeader {
+			headers = append(headers, scheme.Name)
+		}
+	}
+	return headers
+}
+
+// stripCredentials drops the credential headers, the API key query parameters and the Host override of a request
+// following a URL given by the API when it leaves the host of the original request
+func stripCredentials(req *http.Request, origin *url.URL) {
+	if strings.EqualFold(req.URL.Host, origin.Host) {
+		return
+	}
+	req.Host = ""
+	req.Header.Del("Authorization")
+	headers, _ := req.Context().Value(credentialHeadersKey{}).([]string)
+	for _, name := range headers {
+		req.Header.Del(name)
+	}
+	params, _ := req.Context().Value(credentialParamsKey{}).([]string)
+	query := req.URL.Query()
+	for _, name := range params {
+		if query.Has(name) {
+			query.Del(name)
+			req.URL.RawQuery = query.Encode()
+		}
+	}
+}
+
+func (c *Converter) newHandler(client *http.Client, server *openapi3.Server, path, method string, operation *openapi3.Operation, parameters openapi3.Parameters) (server.ToolHandlerFunc, error) {
+	queryParams := getQueryParams(parameters, c.options.QueryBoolFormat)
+	contentParams := getContentParameters(parameters)
+	rawPathParams := getRawPathParameters(parameters)
+	defaults := getParameterDefaults(parameters)
+	security := c.getSecurity(operation)
+	// Handlers only use the document captured here, Reload may replace it while calls are in flight
+	schemes := c.securitySchemes()
+	baseURL := c.baseURL()
+	digestAuth := schemes.usesDigestAuth(security)
+	credentialHeaders := schemes.credentialHeaders(security)
+	credentialParams := schemes.credentialParams(security)
+	sensitive := getSensitiveFields(parameters, operation.RequestBody)
+	for _, scheme := range schemes.apiKeySchemes(security) {
+		sensitive.params[sensitiveParamKey(scheme.In, scheme.Name)] = true
+	}
+	gzipThreshold := c.gzipThreshold(operation)
+	operationID := c.parser.GetOperationID(path, method, operation)
+	fixedURL, err := c.fixedServerURL(operation)
+	if err != nil {
+		return nil, err
+	}
+
+	var mock *mockResponse
+	if c.options.MockMode {
+		var err error
+		mock, err = newMockResponse(operation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build mock response: %w", err)
+		}
+	}
+
+	var bodyContentType string
+	var bodyEncoding map[string]*openapi3.Encoding
+	var graphQL bool
+	if operation.RequestBody != nil {
+		bodyContentType = requestBodyContentType(operation.RequestBody.Value)
+		if operation.RequestBody.Value != nil {
+			if mediaType := operation.RequestBody.Value.Content.Get(bodyContentType); mediaType != nil {
+				bodyEncoding = mediaType.Encoding
+				graphQL = isGraphQLBody(bodyContentType, mediaType)
+			}
+		}
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (_ *mcp.CallToolResult, err error) {
+		// The derived deadline is the sooner of the timeout and the one already on the context
+		if c.options.RequestTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.options.RequestTimeout)
+			defer cancel()
+		}
+
+		arg, err := getArgs(request.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		defaults.apply(&arg, c.options.DefaultQuery, c.options.DefaultHeaders)
+		if err := encodeContentParameters(&arg, contentParams); err != nil {
+			return nil, err
+		}
+		if mock != nil {
+			return c.newToolResult(mock.statusCode, mock.header, mock.body, arg.ResponseFields, arg.ResultPath)
+		}
+		if err := c.checkCredentials(request.Params.Arguments, schemes, security, arg.AuthScheme); err != nil {
+			return nil, err
+		}
 
 		// Build the URL
 		serverURL := arg.ServerAddr
 		if serverURL == "" && server != nil {
 			serverURL = server.URL
 		}
+		if fixedURL != "" {
+			serverURL = fixedURL
+		}
+		if !c.serverAllowed(serverURL) {
+			return nil, fmt.Errorf("server %s is not allowed", serverURL)
+		}
+		serverURL, err = resolveServerURL(serverURL, baseURL)
+		if err != nil {
+			return nil, err
+		}
 
-		// Replace path parameters
+		// Replace path parameters, raw ones keep their slashes as segment separators
 		finalPath := path
 		for paramName, paramValue := range arg.Path {
-			finalPath = strings.ReplaceAll(finalPath, "{"+paramName+"}", fmt.Sprintf("%v", paramValue))
+			value := fmt.Sprintf("%v", paramValue)
+			if rawPathParams[paramName] {
+				segments := strings.Split(value, "/")
+				for i, segment := range segments {
+					segments[i] = escapePathSegment(segment)
+				}
+				value = strings.Join(segments, "/")
+			} else {
+				value = escapePathSegment(value)
+			}
+			finalPath = strings.ReplaceAll(finalPath, "{"+paramName+"}", value)
 		}
 
 		// Build the full URL with query parameters
-		fullURL, err := url.JoinPath(serverURL, finalPath)
+		fullURL, err := url.JoinPath(serverURL, c.options.PathPrefix, finalPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to join URL path %s: %w", fullURL, err)
 		}
@@ -113,122 +723,521 @@ func newHandler(server *openapi3.Server, path, method string, operation *openapi
 			return nil, fmt.Errorf("failed to parse URL %s: %w", fullURL, err)
 		}
 
-		// Add query parameters
-		if len(arg.Query) > 0 {
-			q := parsedURL.Query()
-			for key, value := range arg.Query {
-				q.Add(key, fmt.Sprintf("%v", value))
+		// Add query parameters, default ones unless supplied by the caller
+		for key, value := range c.options.DefaultQuery {
+			if _, ok := arg.Query[key]; !ok {
+				arg.Query[key] = value
 			}
-			parsedURL.RawQuery = q.Encode()
+		}
+		if len(arg.Query) > 0 {
+			parsedURL.RawQuery = encodeQuery(parsedURL.RawQuery, arg.Query, queryParams)
 		}
 
 		// Create the request body if needed
+		contentType, encoding := bodyContentType, bodyEncoding
+		if arg.ContentType != "" {
+			var mediaType *openapi3.MediaType
+			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				mediaType = operation.RequestBody.Value.Content.Get(arg.ContentType)
+			}
+			if mediaType == nil {
+				return nil, fmt.Errorf("unsupported content type %s", arg.ContentType)
+			}
+			contentType, encoding = arg.ContentType, mediaType.Encoding
+		}
+
 		var reqBody io.Reader
-		if arg.Body != nil {
-			bodyBytes, err := json.Marshal(arg.Body)
+		var bodyBytes []byte
+		var reqContentType string
+		var bodyFileSize int64
+		if arg.BodyFile != "" {
+			if arg.Body != nil {
+				return nil, errors.New("body and body file can't be used together")
+			}
+			if c.options.BodyFileRoot == "" {
+				return nil, errors.New("body files are not enabled")
+			}
+			file, size, err := openBodyFile(c.options.BodyFileRoot, arg.BodyFile)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			reqBody, bodyFileSize = file, size
+			reqContentType = contentType
+			if reqContentType == "" {
+				reqContentType = "application/octet-stream"
+			}
+		} else if graphQL && arg.GraphQLQuery != "" {
+			bodyBytes, reqContentType, err = encodeGraphQLBody(contentType, arg.GraphQLQuery, arg.GraphQLVariables, arg.GraphQLOperationName, c.options.PrettyBody)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal request body: %w", err)
+			}
+		} else if arg.Body != nil {
+			bodyBytes, reqContentType, err = encodeBody(contentType, arg.Body, encoding, c.options.PrettyBody)
 			if err != nil {
 				return nil, fmt.Errorf("failed to marshal request body: %w", err)
 			}
+		}
+		gzipped := false
+		if bodyBytes != nil {
+			if gzipThreshold > 0 && len(bodyBytes) >= gzipThreshold {
+				if bodyBytes, err = gzipBody(bodyBytes); err != nil {
+					return nil, fmt.Errorf("failed to compress request body: %w", err)
+				}
+				gzipped = true
+			}
 			reqBody = bytes.NewBuffer(bodyBytes)
 		}
 
-		// Create the HTTP request
-		httpReq, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), parsedURL.String(), reqBody)
+		// Create the HTTP request, tunneling verbs other than GET and POST through POST when overriding
+		requestMethod := strings.ToUpper(method)
+		overridden := c.options.MethodOverride && requestMethod != http.MethodGet && requestMethod != http.MethodPost
+		if overridden {
+			requestMethod = http.MethodPost
+		}
+		// Redirects and URLs returned by the API drop these headers and query parameters when they leave the host
+		ctx = context.WithValue(ctx, credentialHeadersKey{}, credentialHeaders)
+		ctx = context.WithValue(ctx, credentialParamsKey{}, credentialParams)
+		httpReq, err := http.NewRequestWithContext(ctx, requestMethod, parsedURL.String(), reqBody)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 		}
+		if overridden {
+			httpReq.Header.Set("X-HTTP-Method-Override", strings.ToUpper(method))
+		}
+		// Stream body files, reopening them when the request is replayed
+		if arg.BodyFile != "" {
+			httpReq.ContentLength = bodyFileSize
+			httpReq.GetBody = func() (io.ReadCloser, error) {
+				file, _, err := openBodyFile(c.options.BodyFileRoot, arg.BodyFile)
+				return file, err
+			}
+		}
+
+		// Add default headers, headers supplied by the caller take precedence
+		for key, value := range c.options.DefaultHeaders {
+			httpReq.Header.Set(key, value)
+		}
+		if c.options.AcceptLanguage != "" {
+			httpReq.Header.Set("Accept-Language", c.options.AcceptLanguage)
+		}
 
 		// Add headers
 		for key, value := range arg.Headers {
-			httpReq.Header.Add(key, fmt.Sprintf("%v", value))
+			httpReq.Header.Set(key, formatValue(value))
+		}
+
+		// Go sends the Host header from Request.Host and ignores it in Header
+		if host := httpReq.Header.Get("Host"); host != "" {
+			httpReq.Host = host
+			httpReq.Header.Del("Host")
+		}
+
+		// Set content type for requests with body, a Content-Type header supplied by the caller takes
+		// precedence unless the body is multipart, whose boundary is only known to the encoder
+		if reqContentType != "" && (httpReq.Header.Get("Content-Type") == "" || strings.HasPrefix(reqContentType, "multipart/")) {
+			httpReq.Header.Set("Content-Type", reqContentType)
+		}
+		if gzipped {
+			httpReq.Header.Set("Content-Encoding", "gzip")
+		}
+
+		// Ask the API to answer long operations with a status URL to poll
+		if c.options.AsyncPolling != nil && httpReq.Header.Get("Prefer") == "" {
+			httpReq.Header.Set("Prefer", "respond-async")
 		}
 
-		// Set content type for requests with body
-		if arg.Body != nil {
-			httpReq.Header.Set("Content-Type", "application/json")
+		// Attach an idempotency key so the request can be safely retried
+		if c.options.IdempotencyKeyHeader != "" && strings.EqualFold(method, http.MethodPost) &&
+			httpReq.Header.Get(c.options.IdempotencyKeyHeader) == "" {
+			httpReq.Header.Set(c.options.IdempotencyKeyHeader, uuid.NewString())
+		}
+
+		// Only send the credentials of the selected scheme
+		useDigestAuth := digestAuth
+		if arg.AuthScheme != "" {
+			index := slices.IndexFunc(security, func(requirement openapi3.SecurityRequirement) bool {
+				return securityRequirementName(requirement) == arg.AuthScheme
+			})
+			if index < 0 {
+				return nil, fmt.Errorf("unknown auth scheme %s", arg.AuthScheme)
+			}
+			schemes.restrictCredentials(&arg, security[index])
+			useDigestAuth = schemes.usesDigestAuth(security[index : index+1])
 		}
 
 		// Add authentication if provided
+		schemes.setAPIKeys(httpReq, arg.APIKeys, security)
+		var oauth2Token string
 		if arg.AuthToken != "" {
 			httpReq.Header.Set("Authorization", "Bearer "+arg.AuthToken)
-		} else if arg.AuthUsername != "" && arg.AuthPassword != "" {
+		} else if arg.AuthUsername != "" && arg.AuthPassword != "" && !useDigestAuth {
 			httpReq.SetBasicAuth(arg.AuthUsername, arg.AuthPassword)
 		} else if arg.AuthOAuth2Token != "" {
 			httpReq.Header.Set("Authorization", "Bearer "+arg.AuthOAuth2Token)
+		} else if arg.AuthOIDCToken != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+arg.AuthOIDCToken)
+		} else if c.oauth2 != nil && httpReq.Header.Get("Authorization") == "" && !useDigestAuth {
+			oauth2Token, err = c.oauth2.get(ctx, client)
+			if err != nil {
+				return nil, err
+			}
+			httpReq.Header.Set("Authorization", "Bearer "+oauth2Token)
+		} else if c.tokenFile != nil && httpReq.Header.Get("Authorization") == "" && !useDigestAuth {
+			token, err := c.tokenFile.get()
+			if err != nil {
+				return nil, err
+			}
+			if token != "" {
+				httpReq.Header.Set("Authorization", "Bearer "+token)
+			}
 		}
 
 		// For form data
 		if len(arg.Forms) > 0 {
-			formData := url.Values{}
-			for key, value := range arg.Forms {
-				switch value := value.(type) {
-				case map[string]any:
-					jsonStr, err := json.Marshal(value)
-					if err != nil {
-						return nil, err
-					}
-					formData.Add(key, string(jsonStr))
-				default:
-					formData.Add(key, fmt.Sprintf("%v", value))
-				}
+			formData, err := formValues(arg.Forms)
+			if err != nil {
+				return nil, err
+			}
+			encoded := formData.Encode()
+			if httpReq.Header.Get("Content-Type") == "" {
+				httpReq.Header.Set("Content-Type", contentTypeForm)
+			}
+			httpReq.Body = io.NopCloser(strings.NewReader(encoded))
+			httpReq.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(encoded)), nil
 			}
-			httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-			httpReq.Body = io.NopCloser(strings.NewReader(formData.Encode()))
+			httpReq.ContentLength = int64(len(encoded))
 		}
 
-		resp, err := http.DefaultClient.Do(httpReq)
+		var cacheKey string
+		if c.cache != nil && httpReq.Method == http.MethodGet {
+			cacheKey = responseCacheKey(httpReq)
+			if cached, ok := c.cache.get(cacheKey); ok {
+				return c.newToolResult(cached.statusCode, cached.header, cached.body, arg.ResponseFields, arg.ResultPath)
+			}
+		}
+
+		if c.options.LogRequests {
+			sensitive.logRequest(httpReq, arg)
+		}
+
+		stopProgress := reportProgress(ctx, request)
+		defer stopProgress()
+
+		var statusCode int
+		if c.options.OnResponse != nil {
+			start := time.Now()
+			defer func() {
+				c.options.OnResponse(operationID, statusCode, time.Since(start), err)
+			}()
+		}
+
+		resp, err := client.Do(httpReq)
 		if err != nil {
-			return nil, fmt.Errorf("request failed: %w", err)
+			return nil, fmt.Errorf("request failed: %w", sensitive.redactError(err))
+		}
+		statusCode = resp.StatusCode
+		if useDigestAuth && resp.StatusCode == http.StatusUnauthorized && arg.AuthUsername != "" {
+			resp, err = doDigestAuth(client, httpReq, resp, arg.AuthUsername, arg.AuthPassword)
+			if err != nil {
+				return nil, fmt.Errorf("digest authentication failed: %w", sensitive.redactError(err))
+			}
+		}
+		if c.options.AsyncPolling != nil {
+			resp, err = c.pollAsync(ctx, client, httpReq, resp)
+			if err != nil {
+				return nil, err
+			}
 		}
 		defer resp.Body.Close()
+		statusCode = resp.StatusCode
+		// A rejected access token may have been revoked before its expiry
+		if oauth2Token != "" && resp.StatusCode == http.StatusUnauthorized {
+			c.oauth2.invalidate(oauth2Token)
+		}
+
+		// Conditional requests answered from the client's cache have no body
+		if resp.StatusCode == http.StatusNotModified {
+			text := fmt.Sprintf("status code: %d\nnot modified: the resource has not changed since the cached version", resp.StatusCode)
+			if etag := resp.Header.Get("ETag"); etag != "" {
+				text += "\netag: " + etag
+			}
+			return mcp.NewToolResultText(text), nil
+		}
 
 		result, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return nil, fmt.Errorf("read response error: %w", err)
 		}
-		return mcp.NewToolResultText(fmt.Sprintf("status code: %d\nresponse body: %s", resp.StatusCode, result)), nil
+
+		if c.options.Pagination != nil && c.options.Pagination.MaxPages > 1 && httpReq.Method == http.MethodGet &&
+			resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			result, err = c.followPages(ctx, client, httpReq, resp.Header, result)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if cacheKey != "" && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			c.cache.set(cacheKey, resp.StatusCode, resp.Header, result)
+		}
+
+		toolResult, err := c.newToolResult(resp.StatusCode, resp.Header, result, arg.ResponseFields, arg.ResultPath)
+		if err != nil {
+			return nil, err
+		}
+		if c.options.AuditMode {
+			audit, err := sensitive.auditContent(httpReq, resp)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build audit record: %w", err)
+			}
+			toolResult.Content = append(toolResult.Content, audit)
+		}
+		return toolResult, nil
 	}, nil
 }
 
+// operationServers returns the allowed servers of an operation and the one used by default.
+// Servers declared by the operation or its path replace those of the document, the first
+// of them being the default, document servers are only defaulted when there is a single one.
+func (c *Converter) operationServers(pathItem *openapi3.PathItem, operation *openapi3.Operation) ([]*openapi3.Server, *openapi3.Server) {
+	var servers []*openapi3.Server
+	declared := true
+	switch {
+	case operation.Servers != nil && len(*operation.Servers) > 0:
+		servers = *operation.Servers
+	case len(pathItem.Servers) > 0:
+		servers = pathItem.Servers
+	default:
+		servers = c.parser.GetServers()
+		declared = false
+	}
+
+	servers = c.allowedServers(servers)
+	if len(servers) == 1 || (declared && len(servers) > 0) {
+		return servers, servers[0]
+	}
+	return servers, nil
+}
+
+// fixedServerURL returns the upstream URL an operation pins with x-mcp-server-url, replacing
+// the servers of the spec and the server_addr argument, it must be absolute and allowed
+func (c *Converter) fixedServerURL(operation *openapi3.Operation) (string, error) {
+	serverURL, ok := getStringExtension(operation.Extensions, "x-mcp-server-url")
+	if !ok || serverURL == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(serverURL)
+	if err != nil || !parsed.IsAbs() {
+		return "", fmt.Errorf("x-mcp-server-url %s is not an absolute URL", serverURL)
+	}
+	if !c.serverAllowed(serverURL) {
+		return "", fmt.Errorf("x-mcp-server-url %s is not allowed", serverURL)
+	}
+	return serverURL, nil
+}
+
+// allowedServers filters the servers by the allowed servers option
+func (c *Converter) allowedServers(servers []*openapi3.Server) []*openapi3.Server {
+	if len(c.options.AllowedServers) == 0 {
+		return servers
+	}
+	return slices.DeleteFunc(slices.Clone(servers), func(server *openapi3.Server) bool {
+		return !c.serverAllowed(server.URL)
+	})
+}
+
+// serverAllowed reports whether requests may be sent to the server URL, ignoring trailing slashes
+func (c *Converter) serverAllowed(serverURL string) bool {
+	if len(c.options.AllowedServers) == 0 {
+		return true
+	}
+	serverURL = strings.TrimSuffix(serverURL, "/")
+	return slices.ContainsFunc(c.options.AllowedServers, func(allowed string) bool {
+		return strings.TrimSuffix(allowed, "/") == serverURL
+	})
+}
+
+// getRawPathParameters returns the path parameters flagged with x-mcp-raw-path,
+// whose slashes aren't escaped so the value may span several path segments
+func getRawPathParameters(parameters openapi3.Parameters) map[string]bool {
+	params := make(map[string]bool)
+	for _, paramRef := range parameters {
+		param := paramRef.Value
+		if param == nil || param.In != openapi3.ParameterInPath {
+			continue
+		}
+		if raw, _ := getBoolExtension(param.Extensions, "x-mcp-raw-path"); raw {
+			params[param.Name] = true
+		}
+	}
+	return params
+}
+
+// escapePathSegment percent-encodes a path segment, values that are already
+// percent-encoded are kept as is since escaping them again would corrupt them
+func escapePathSegment(segment string) string {
+	if strings.Contains(segment, "%") {
+		if unescaped, err := url.PathUnescape(segment); err == nil && url.PathEscape(unescaped) == segment {
+			return segment
+		}
+	}
+	return url.PathEscape(segment)
+}
+
+// baseURL returns the URL relative server URLs are resolved against, the source URL of the document by default
+func (c *Converter) baseURL() string {
+	if c.options.BaseURL != "" {
+		return c.options.BaseURL
+	}
+	return c.parser.GetSourceURL()
+}
+
+// resolveServerURL resolves a relative server URL against the base URL
+func resolveServerURL(serverURL, baseURL string) (string, error) {
+	ref, err := url.Parse(serverURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL %s: %w", serverURL, err)
+	}
+	if ref.IsAbs() {
+		return serverURL, nil
+	}
+
+	if baseURL == "" {
+		return "", fmt.Errorf("relative server URL %s requires a base URL", serverURL)
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %s: %w", baseURL, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// newToolResult converts an upstream response into the tool call result,
+// successful bodies are projected to the requested fields
+func (c *Converter) newToolResult(statusCode int, header http.Header, body []byte, fields []string, resultPath string) (*mcp.CallToolResult, error) {
+	// Responses without a content type are assumed to be JSON
+	contentType := header.Get("Content-Type")
+	if statusCode >= 200 && statusCode < 300 && (contentType == "" || c.isJSONContentType(contentType)) {
+		var err error
+		if body, err = extractPointer(body, resultPath); err != nil {
+			return nil, err
+		}
+		body = projectResponse(body, fields)
+	}
+	// Problem details are summarized ahead of the body so the error reads at a glance
+	problem := problemSummary(contentType, body)
+	if c.responses != nil {
+		uri := c.responses.add(contentType, string(body))
+		return mcp.NewToolResultText(fmt.Sprintf("status code: %d\n%sresponse body stored as resource: %s", statusCode, problem, uri)), nil
+	}
+	if c.options.CSVSummary != nil && statusCode >= 200 && statusCode < 300 {
+		body = c.summarizeCSV(contentType, body)
+	}
+	if c.options.SplitResponseContent {
+		status := fmt.Sprintf("status code: %d", statusCode)
+		if contentType != "" {
+			status += "\ncontent type: " + contentType
+		}
+		if problem != "" {
+			status += "\n" + strings.TrimSuffix(problem, "\n")
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.NewTextContent(status),
+				mcp.NewTextContent(string(body)),
+			},
+		}, nil
+	}
+	// Failed responses keep the status code so it is still conveyed to the client
+	if c.options.RawResponseBody && statusCode >= 200 && statusCode < 300 {
+		return mcp.NewToolResultText(string(body)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("status code: %d\n%sresponse body: %s", statusCode, problem, body)), nil
+}
+
 type Args struct {
 	ServerAddr      string
 	AuthToken       string
 	AuthUsername    string
 	AuthPassword    string
 	AuthOAuth2Token string
-	Headers         map[string]any
-	Body            any
-	Query           map[string]any
-	Path            map[string]any
-	Forms           map[string]any
+	AuthOIDCToken   string
+	AuthScheme      string
+	ContentType     string
+	BodyFile        string
+	ResponseFields  []string
+	ResultPath      string
+	// GraphQLQuery, GraphQLVariables and GraphQLOperationName build the body of operations taking a GraphQL query
+	GraphQLQuery         string
+	GraphQLVariables     map[string]any
+	GraphQLOperationName string
+	Headers              map[string]any
+	Body                 any
+	Query                map[string]any
+	Path                 map[string]any
+	Forms                map[string]any
+	// APIKeys holds the keys of apiKey security schemes by scheme name
+	APIKeys map[string]string
 }
 
-func getArgs(args map[string]interface{}) Args {
+// getArgs sorts the tool arguments by kind, unknown openapi| meta keys are rejected
+// except auth_ prefixed ones which carry API keys
+func getArgs(args map[string]interface{}) (Args, error) {
 	arg := Args{
 		Headers: make(map[string]any),
 		Query:   make(map[string]any),
 		Path:    make(map[string]any),
 		Forms:   make(map[string]any),
+		APIKeys: make(map[string]string),
 	}
 	for k, v := range args {
+		var err error
 		switch {
 		case strings.HasPrefix(k, "openapi|"):
 			switch strings.TrimPrefix(k, "openapi|") {
 			case "server_addr":
-				arg.ServerAddr = v.(string)
+				arg.ServerAddr, err = stringArg(k, v)
 			case "auth_token":
-				arg.AuthToken = v.(string)
+				arg.AuthToken, err = stringArg(k, v)
 			case "auth_username":
-				arg.AuthUsername = v.(string)
+				arg.AuthUsername, err = stringArg(k, v)
 			case "auth_password":
-				arg.AuthPassword = v.(string)
+				arg.AuthPassword, err = stringArg(k, v)
 			case "auth_oauth2_token":
-				arg.AuthOAuth2Token = v.(string)
+				arg.AuthOAuth2Token, err = stringArg(k, v)
+			case "auth_oidc_token":
+				arg.AuthOIDCToken, err = stringArg(k, v)
+			case "auth_scheme":
+				arg.AuthScheme, err = stringArg(k, v)
+			case "content_type":
+				arg.ContentType, err = stringArg(k, v)
+			case "body_file":
+				arg.BodyFile, err = stringArg(k, v)
+			case "result_path":
+				arg.ResultPath, err = stringArg(k, v)
+			case "response_fields":
+				fields, _ := v.([]any)
+				for _, field := range fields {
+					if field, ok := field.(string); ok {
+						arg.ResponseFields = append(arg.ResponseFields, field)
+					}
+				}
 			default:
-				arg.AuthToken = v.(string)
+				if !strings.HasPrefix(k, "openapi|auth_") {
+					return Args{}, fmt.Errorf("unknown argument %s", k)
+				}
+				arg.APIKeys[strings.TrimPrefix(k, "openapi|auth_")], err = stringArg(k, v)
 			}
 		case k == "body":
 			arg.Body = v
+		case k == "query":
+			arg.GraphQLQuery, _ = v.(string)
+		case k == "variables":
+			arg.GraphQLVariables, _ = v.(map[string]any)
+		case k == "operationName":
+			arg.GraphQLOperationName, _ = v.(string)
 		case strings.HasPrefix(k, "query|"):
 			arg.Query[strings.TrimPrefix(k, "query|")] = v
 		case strings.HasPrefix(k, "path|"):
@@ -238,8 +1247,20 @@ func getArgs(args map[string]interface{}) Args {
 		case strings.HasPrefix(k, "formData|"):
 			arg.Forms[strings.TrimPrefix(k, "formData|")] = v
 		}
+		if err != nil {
+			return Args{}, err
+		}
+	}
+	return arg, nil
+}
+
+// stringArg returns the value of an openapi| argument, which must be a string
+func stringArg(k string, v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", k)
 	}
-	return arg
+	return s, nil
 }
 
 // getOperations returns a map of HTTP method to operation
@@ -274,44 +1295,182 @@ func getOperations(pathItem *openapi3.PathItem) map[string]*openapi3.Operation {
 	return operations
 }
 
-// convertOperation converts an OpenAPI operation to an MCP tool
-func (c *Converter) convertOperation(path, method string, operation *openapi3.Operation) (*mcp.Tool, error) {
-	// Generate a tool name
-	toolName := c.parser.GetOperationID(path, method, operation)
-	if c.options.ToolNamePrefix != "" {
-		toolName = c.options.ToolNamePrefix + toolName
+// parameterDefaults holds the schema defaults of query and header parameters by name
+type parameterDefaults struct {
+	query   map[string]any
+	headers map[string]any
+}
+
+// getParameterDefaults collects the schema defaults of query and header parameters
+func getParameterDefaults(parameters openapi3.Parameters) parameterDefaults {
+	defaults := parameterDefaults{
+		query:   make(map[string]any),
+		headers: make(map[string]any),
+	}
+	for _, paramRef := range parameters {
+		param := paramRef.Value
+		if param == nil || param.Schema == nil || param.Schema.Value == nil || param.Schema.Value.Default == nil {
+			continue
+		}
+		switch param.In {
+		case openapi3.ParameterInQuery:
+			defaults.query[param.Name] = param.Schema.Value.Default
+		case openapi3.ParameterInHeader:
+			defaults.headers[param.Name] = param.Schema.Value.Default
+		}
+	}
+	return defaults
+}
+
+// apply fills the parameters omitted by the caller with their defaults, explicitly provided values
+// are kept even when empty and the default query parameters and headers of the options take precedence
+func (d parameterDefaults) apply(arg *Args, defaultQuery, defaultHeaders map[string]string) {
+	for name, value := range d.query {
+		if _, ok := defaultQuery[name]; ok {
+			continue
+		}
+		if _, ok := arg.Query[name]; !ok {
+			arg.Query[name] = value
+		}
+	}
+	for name, value := range d.headers {
+		if hasHeader(defaultHeaders, name) {
+			continue
+		}
+		if _, ok := arg.Headers[name]; !ok {
+			arg.Headers[name] = value
+		}
+	}
+}
+
+// hasHeader reports whether the headers contain the name, ignoring case
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeParameters combines path-level and operation-level parameters,
+// operation-level parameters override path-level ones with the same name and location
+func mergeParameters(pathParams, operationParams openapi3.Parameters) openapi3.Parameters {
+	if len(pathParams) == 0 {
+		return operationParams
 	}
 
-	args, err := c.convertParameters(operation.Parameters)
+	merged := make(openapi3.Parameters, 0, len(pathParams)+len(operationParams))
+	for _, paramRef := range pathParams {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		if operationParams.GetByInAndName(paramRef.Value.In, paramRef.Value.Name) != nil {
+			continue
+		}
+		merged = append(merged, paramRef)
+	}
+
+	return append(merged, operationParams...)
+}
+
+// uniqueParameters drops parameters repeating the name and location of an earlier one,
+// so the tool schema, query serialization and defaults all follow the first declaration
+func (c *Converter) uniqueParameters(path, method string, parameters openapi3.Parameters) openapi3.Parameters {
+	// Parameters are identified by name and location, the same name may appear in several locations
+	seen := make(map[string]bool, len(parameters))
+	unique := make(openapi3.Parameters, 0, len(parameters))
+	for _, paramRef := range parameters {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		param := paramRef.Value
+		key := param.In + "|" + param.Name
+		if seen[key] {
+			c.warn(ConversionWarning{
+				Path:    path,
+				Method:  method,
+				Feature: key,
+				Message: fmt.Sprintf("duplicate %s parameter %q was ignored", param.In, param.Name),
+			})
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, paramRef)
+	}
+	return unique
+}
+
+// toolName returns the name of the tool generated for an operation,
+// an x-mcp-name extension replaces the operation ID while the prefix and suffix still apply
+func (c *Converter) toolName(path, method string, operation *openapi3.Operation) string {
+	if name, ok := getStringExtension(operation.Extensions, "x-mcp-name"); ok && name != "" {
+		return c.options.ToolNamePrefix + name + c.options.ToolNameSuffix
+	}
+	name := c.parser.GetOperationID(path, method, operation)
+	// Generated operation IDs already start with the method
+	if c.options.IncludeMethodInName && operation.OperationID != "" {
+		name = strings.ToLower(method) + "_" + name
+	}
+	// Generated operation IDs already contain the version segment of the path
+	if c.options.VersionInName {
+		if version, inPath := c.apiVersion(path); version != "" && (!inPath || operation.OperationID != "") {
+			name = versionName(version) + "_" + name
+		}
+	}
+	return c.options.ToolNamePrefix + name + c.options.ToolNameSuffix
+}
+
+// convertOperation converts an OpenAPI operation to an MCP tool, returning the source operation it was generated from
+func (c *Converter) convertOperation(path, method string, operation *openapi3.Operation, parameters openapi3.Parameters, servers []*openapi3.Server, defaultServer *openapi3.Server) (*mcp.Tool, OperationInfo, error) {
+	c.current = OperationInfo{Method: method, Path: path}
+
+	// Generate a tool name
+	toolName := c.toolName(path, method, operation)
+
+	args, err := c.convertParameters(parameters, getStringsExtension(operation.Extensions, "x-mcp-optional"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert parameters: %w", err)
+		return nil, OperationInfo{}, fmt.Errorf("failed to convert parameters: %w", err)
 	}
 
 	// Handle request body if present
 	if operation.RequestBody != nil && operation.RequestBody.Value != nil {
 		bodyArgs, err := c.convertRequestBody(operation.RequestBody.Value)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert request body: %w", err)
+			return nil, OperationInfo{}, fmt.Errorf("failed to convert request body: %w", err)
 		}
 		args = append(args, bodyArgs...)
 	}
 
-	// Add server address parameter
-	servers := c.parser.GetServers()
-	if len(servers) == 0 {
+	// Let the caller override the default language unless the spec already declares the header
+	if c.options.AcceptLanguage != "" && parameters.GetByInAndName(openapi3.ParameterInHeader, "Accept-Language") == nil {
+		args = append(args, mcp.WithString("header|Accept-Language",
+			mcp.Description("Preferred language of the response"),
+			mcp.DefaultString(c.options.AcceptLanguage)))
+	}
+
+	// Add server address parameter, unless the operation pins its server
+	fixedURL, err := c.fixedServerURL(operation)
+	if err != nil {
+		return nil, OperationInfo{}, err
+	}
+	switch {
+	case fixedURL != "":
+		// The caller can't choose the server
+	case len(servers) == 0:
 		args = append(args, mcp.WithString("openapi|server_addr",
 			mcp.Description("Server address to connect to"),
 			mcp.Required()))
-	} else if len(servers) == 1 {
+	case defaultServer != nil:
 		serverUrls := make([]string, 0, len(servers))
 		for _, server := range servers {
 			serverUrls = append(serverUrls, server.URL)
 		}
 		args = append(args, mcp.WithString("openapi|server_addr",
 			mcp.Description("Server address to connect to"),
-			mcp.DefaultString(servers[0].URL),
+			mcp.DefaultString(defaultServer.URL),
 			mcp.Enum(serverUrls...)))
-	} else {
+	default:
 		serverUrls := make([]string, 0, len(servers))
 		for _, server := range servers {
 			serverUrls = append(serverUrls, server.URL)
@@ -322,14 +1481,41 @@ func (c *Converter) convertOperation(path, method string, operation *openapi3.Op
 			mcp.Enum(serverUrls...)))
 	}
 
+	// Allow projecting JSON responses to the fields the caller needs
+	if slices.ContainsFunc(successMediaTypes(operation), c.isJSONContentType) {
+		args = append(args, mcp.WithArray("openapi|response_fields",
+			mcp.Description("Only return these fields of the JSON response, as dot paths like data.id, paths apply to each element of arrays"),
+			mcp.Items(map[string]any{"type": "string"})))
+		args = append(args, mcp.WithString("openapi|result_path",
+			mcp.Description("Only return the subtree of the JSON response at this JSON Pointer, like /data/0/name, applied before the response fields")))
+	}
+
 	// Handle security requirements if present and enabled
-	if operation.Security != nil && len(*operation.Security) > 0 {
-		securityArgs := c.convertSecurityRequirements(*operation.Security)
+	if security := c.getSecurity(operation); len(security) > 0 {
+		securityArgs := c.convertSecurityRequirements(security)
 		args = append(args, securityArgs...)
 	}
 
 	// Create description that includes summary, description, and response information
 	description := getDescription(operation)
+	if c.options.IncludeAPIContext {
+		description = appendDescription(getAPIContext(c.parser.GetDocument()), description)
+	}
+
+	// Record the source operation so the tool can be traced back to the spec
+	version, _ := c.apiVersion(path)
+	info := OperationInfo{
+		OperationID:          operation.OperationID,
+		Method:               strings.ToUpper(method),
+		Path:                 path,
+		Tags:                 operation.Tags,
+		Version:              version,
+		RequiresConfirmation: requiresConfirmation(method, operation),
+	}
+	if info.RequiresConfirmation {
+		description = appendDescription(description, "IMPORTANT: This operation requires user confirmation before it is called.")
+	}
+	description = appendDescription(description, info.String())
 
 	// Add response information to description
 	if operation.Responses != nil {
@@ -339,13 +1525,27 @@ func (c *Converter) convertOperation(path, method string, operation *openapi3.Op
 		}
 	}
 
+	// Callbacks can't be called but tell the agent the operation triggers requests back to the caller
+	if callbackDesc := describeCallbacks(operation.Callbacks); callbackDesc != "" {
+		description += "\n\nCallbacks sent by the API after this operation:\n\n" + callbackDesc
+	}
+
+	// An x-mcp-description written for agents replaces the generated description,
+	// only keeping the confirmation notice
+	if custom, ok := getStringExtension(operation.Extensions, "x-mcp-description"); ok && custom != "" {
+		description = custom
+		if info.RequiresConfirmation {
+			description = appendDescription(description, "IMPORTANT: This operation requires user confirmation before it is called.")
+		}
+	}
+
 	args = append(args, mcp.WithDescription(description))
 
 	tool := mcp.NewTool(toolName,
 		args...,
 	)
 
-	return &tool, nil
+	return &tool, info, nil
 }
 
 // generateResponseDescription creates a human-readable description of possible responses
@@ -353,10 +1553,15 @@ func (c *Converter) generateResponseDescription(responses openapi3.Responses) st
 	respMap := responses.Map()
 	responseDescriptions := make([]string, 0, len(respMap))
 
-	for code, responseRef := range respMap {
+	// Responses are described in a stable order so exported tools can be diffed
+	for _, code := range slices.Sorted(maps.Keys(respMap)) {
+		responseRef := respMap[code]
 		if responseRef == nil || responseRef.Value == nil {
 			continue
 		}
+		if len(c.options.ResponseCodes) > 0 && !matchResponseCode(code, c.options.ResponseCodes) {
+			continue
+		}
 
 		response := responseRef.Value
 		desc := fmt.Sprintf("- status: %s, description: %s", code, *response.Description)
@@ -382,7 +1587,8 @@ func (c *Converter) generateResponseDescription(responses openapi3.Responses) st
 		}
 
 		if len(response.Content) > 0 {
-			for contentType, mediaType := range response.Content {
+			for _, contentType := range slices.Sorted(maps.Keys(response.Content)) {
+				mediaType := response.Content[contentType]
 				if mediaType.Schema != nil && mediaType.Schema.Value != nil {
 					property := c.processSchemaProperty(mediaType.Schema.Value, make(map[string]bool))
 					str, err := json.Marshal(property)
@@ -397,115 +1603,686 @@ func (c *Converter) generateResponseDescription(responses openapi3.Responses) st
 		responseDescriptions = append(responseDescriptions, desc)
 	}
 
-	return strings.Join(responseDescriptions, "\n\n")
+	return strings.Join(responseDescriptions, "\n\n")
+}
+
+// describeCallbacks lists the requests the API sends to the callback URLs of an operation
+func describeCallbacks(callbacks openapi3.Callbacks) string {
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(callbacks)) {
+		callbackRef := callbacks[name]
+		if callbackRef == nil || callbackRef.Value == nil {
+			continue
+		}
+		paths := callbackRef.Value.Map()
+		for _, expression := range slices.Sorted(maps.Keys(paths)) {
+			operations := getOperations(paths[expression])
+			for _, method := range slices.Sorted(maps.Keys(operations)) {
+				line := fmt.Sprintf("- %s: %s %s", name, strings.ToUpper(method), expression)
+				if summary := getDescription(operations[method]); summary != "" {
+					line += ", " + strings.ReplaceAll(summary, "\n\n", " ")
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// matchResponseCode reports whether a response code matches any of the patterns
+func matchResponseCode(code string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.EqualFold(pattern, code) {
+			return true
+		}
+		// Match status classes such as 2XX against both concrete codes and class keys
+		if len(pattern) == 3 && strings.EqualFold(pattern[1:], "XX") &&
+			len(code) == 3 && pattern[0] == code[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// getSecurity returns the security requirements of an operation, falling back to the document-level requirements
+func (c *Converter) getSecurity(operation *openapi3.Operation) openapi3.SecurityRequirements {
+	if operation.Security != nil {
+		return *operation.Security
+	}
+	return c.parser.GetDocument().Security
+}
+
+// convertSecurityRequirements converts OpenAPI security requirements to MCP arguments
+func (c *Converter) convertSecurityRequirements(securityRequirements openapi3.SecurityRequirements) []mcp.ToolOption {
+	args := []mcp.ToolOption{}
+
+	// Get security definitions from the document
+	components := c.parser.GetDocument().Components
+	if components == nil {
+		return nil
+	}
+	securitySchemes := components.SecuritySchemes
+	if len(securitySchemes) == 0 {
+		return nil
+	}
+
+	// Credentials of alternative requirements are optional, the caller picks one with the auth scheme argument
+	required := mcp.Required()
+	if len(securityRequirements) > 1 {
+		required = func(map[string]any) {}
+		names := make([]string, 0, len(securityRequirements))
+		for _, requirement := range securityRequirements {
+			names = append(names, securityRequirementName(requirement))
+		}
+		args = append(args, mcp.WithString("openapi|auth_scheme",
+			mcp.Description("Authentication scheme to use, only the credentials of this scheme are sent"),
+			mcp.Enum(names...)))
+	}
+
+	// Process each security requirement
+	for _, requirement := range securityRequirements {
+		for _, schemeName := range slices.Sorted(maps.Keys(requirement)) {
+			scopes := requirement[schemeName]
+			schemeRef := securitySchemes[schemeName]
+			if schemeRef == nil || schemeRef.Value == nil {
+				continue
+			}
+
+			scheme := schemeRef.Value
+			switch scheme.Type {
+			case "apiKey":
+				args = append(args, mcp.WithString("openapi|auth_"+schemeName,
+					mcp.Description(fmt.Sprintf("API Key for %s authentication (in %s named '%s')",
+						schemeName, scheme.In, scheme.Name)),
+					required))
+			case "http":
+				switch strings.ToLower(scheme.Scheme) {
+				case "basic":
+					args = append(args, mcp.WithString("openapi|auth_username",
+						mcp.Description("Username for Basic authentication"),
+						required))
+					args = append(args, mcp.WithString("openapi|auth_password",
+						mcp.Description("Password for Basic authentication"),
+						required))
+				case "digest":
+					args = append(args, mcp.WithString("openapi|auth_username",
+						mcp.Description("Username for Digest authentication"),
+						required))
+					args = append(args, mcp.WithString("openapi|auth_password",
+						mcp.Description("Password for Digest authentication"),
+						required))
+				case "bearer":
+					args = append(args, mcp.WithString("openapi|auth_token",
+						mcp.Description("Bearer token for authentication"),
+						required))
+				}
+			case "oauth2":
+				if len(scopes) > 0 {
+					scopeDesc := "OAuth2 token with scopes: " + strings.Join(scopes, ", ")
+					args = append(args, mcp.WithString("openapi|auth_oauth2_token",
+						mcp.Description(scopeDesc),
+						required))
+				} else {
+					args = append(args, mcp.WithString("openapi|auth_oauth2_token",
+						mcp.Description("OAuth2 token for authentication"),
+						required))
+				}
+			case "openIdConnect":
+				desc := "OpenID Connect token for authentication"
+				if scheme.OpenIdConnectUrl != "" {
+					desc += fmt.Sprintf(" (discovery: %s)", scheme.OpenIdConnectUrl)
+				}
+				args = append(args, mcp.WithString("openapi|auth_oidc_token",
+					mcp.Description(desc),
+					required))
+			}
+		}
+	}
+
+	return args
+}
+
+// securityRequirementName names a security requirement by its sorted scheme names joined with +,
+// the empty requirement allowing anonymous access is named none
+func securityRequirementName(requirement openapi3.SecurityRequirement) string {
+	if len(requirement) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(requirement))
+	for name := range requirement {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "+")
+}
+
+// securitySchemes are the security schemes declared by a document by name
+type securitySchemes map[string]*openapi3.SecurityScheme
+
+// securitySchemes returns the security schemes declared by the document
+func (c *Converter) securitySchemes() securitySchemes {
+	schemes := make(securitySchemes)
+	if components := c.parser.GetDocument().Components; components != nil {
+		for name, schemeRef := range components.SecuritySchemes {
+			if schemeRef != nil && schemeRef.Value != nil {
+				schemes[name] = schemeRef.Value
+			}
+		}
+	}
+	return schemes
+}
+
+// credentialArgs returns the arguments carrying the credentials of the schemes of a security requirement
+func (s securitySchemes) credentialArgs(requirement openapi3.SecurityRequirement) []string {
+	var args []string
+	for _, schemeName := range slices.Sorted(maps.Keys(requirement)) {
+		scheme := s[schemeName]
+		if scheme == nil {
+			continue
+		}
+		switch scheme.Type {
+		case "apiKey":
+			args = append(args, "openapi|auth_"+schemeName)
+		case "http":
+			switch strings.ToLower(scheme.Scheme) {
+			case "basic", "digest":
+				args = append(args, "openapi|auth_username", "openapi|auth_password")
+			case "bearer":
+				args = append(args, "openapi|auth_token")
+			}
+		case "oauth2":
+			args = append(args, "openapi|auth_oauth2_token")
+		case "openIdConnect":
+			args = append(args, "openapi|auth_oidc_token")
+		}
+	}
+	return args
+}
+
+// checkCredentials reports the credential arguments missing to satisfy the security requirements,
+// the credentials of one alternative, or of the selected auth scheme, are enough
+func (c *Converter) checkCredentials(args map[string]any, schemes securitySchemes, security openapi3.SecurityRequirements, authScheme string) error {
+	// Credentials supplied by the deployment can't be checked
+	if len(security) == 0 || c.tokenFile != nil || c.oauth2 != nil {
+		return nil
+	}
+
+	var names []string
+	var missing []string
+	for _, requirement := range security {
+		name := securityRequirementName(requirement)
+		if authScheme != "" && name != authScheme {
+			continue
+		}
+		// Schemes whose credential is already sent in a header, query parameter or cookie need no argument
+		pending := make(openapi3.SecurityRequirement)
+		for schemeName, scopes := range requirement {
+			if scheme := schemes[schemeName]; scheme == nil || !c.credentialSupplied(args, scheme) {
+				pending[schemeName] = scopes
+			}
+		}
+		var requirementMissing []string
+		for _, key := range schemes.credentialArgs(pending) {
+			if value, _ := args[key].(string); value == "" && !slices.Contains(requirementMissing, key) {
+				requirementMissing = append(requirementMissing, key)
+			}
+		}
+		if len(requirementMissing) == 0 {
+			return nil
+		}
+		names = append(names, name)
+		if missing == nil {
+			missing = requirementMissing
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("missing credential %s", strings.Join(missing, ", "))
+	default:
+		return fmt.Errorf("missing credentials, supply those of one of the auth schemes %s", strings.Join(names, ", "))
+	}
+}
+
+// credentialSupplied reports whether the credential of a scheme is sent by a default or caller-supplied
+// header, query parameter or cookie instead of its credential arguments
+func (c *Converter) credentialSupplied(args map[string]any, scheme *openapi3.SecurityScheme) bool {
+	if scheme.Type != "apiKey" {
+		return c.headerValue(args, "Authorization") != ""
+	}
+	switch scheme.In {
+	case openapi3.ParameterInHeader:
+		return c.headerValue(args, scheme.Name) != ""
+	case openapi3.ParameterInQuery:
+		if value, ok := args["query|"+scheme.Name]; ok {
+			return formatValue(value) != ""
+		}
+		return c.options.DefaultQuery[scheme.Name] != ""
+	case openapi3.ParameterInCookie:
+		cookies, _ := http.ParseCookie(c.headerValue(args, "Cookie"))
+		for _, cookie := range cookies {
+			if cookie.Name == scheme.Name && cookie.Value != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headerValue returns the value a request is sent with for a header,
+// supplied by the caller or else by the default headers
+func (c *Converter) headerValue(args map[string]any, name string) string {
+	for key, value := range args {
+		if header, ok := strings.CutPrefix(key, "header|"); ok && strings.EqualFold(header, name) {
+			return formatValue(value)
+		}
+	}
+	for key, value := range c.options.DefaultHeaders {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// restrictCredentials clears the credentials not used by the schemes of the security requirement
+func (s securitySchemes) restrictCredentials(arg *Args, requirement openapi3.SecurityRequirement) {
+	var token, basic, oauth2, oidc bool
+	for schemeName := range requirement {
+		scheme := s[schemeName]
+		if scheme == nil {
+			continue
+		}
+		switch scheme.Type {
+		case "http":
+			if strings.EqualFold(scheme.Scheme, "bearer") {
+				token = true
+			} else {
+				basic = true
+			}
+		case "oauth2":
+			oauth2 = true
+		case "openIdConnect":
+			oidc = true
+		}
+	}
+
+	for schemeName := range arg.APIKeys {
+		if _, ok := requirement[schemeName]; !ok {
+			delete(arg.APIKeys, schemeName)
+		}
+	}
+	if !token {
+		arg.AuthToken = ""
+	}
+	if !basic {
+		arg.AuthUsername, arg.AuthPassword = "", ""
+	}
+	if !oauth2 {
+		arg.AuthOAuth2Token = ""
+	}
+	if !oidc {
+		arg.AuthOIDCToken = ""
+	}
+}
+
+// apiKeySchemes returns the apiKey schemes used by the security requirements by scheme name
+func (s securitySchemes) apiKeySchemes(security openapi3.SecurityRequirements) securitySchemes {
+	schemes := make(securitySchemes)
+	for _, requirement := range security {
+		for schemeName := range requirement {
+			if scheme := s[schemeName]; scheme != nil && scheme.Type == "apiKey" {
+				schemes[schemeName] = scheme
+			}
+		}
+	}
+	return schemes
+}
+
+// setAPIKeys sends each supplied API key in the header, query parameter or cookie named by its scheme,
+// keys of schemes the operation doesn't use are ignored
+func (s securitySchemes) setAPIKeys(req *http.Request, keys map[string]string, security openapi3.SecurityRequirements) {
+	if len(keys) == 0 {
+		return
+	}
+	schemes := s.apiKeySchemes(security)
+	for _, schemeName := range slices.Sorted(maps.Keys(keys)) {
+		scheme, key := schemes[schemeName], keys[schemeName]
+		if scheme == nil || key == "" {
+			continue
+		}
+		switch scheme.In {
+		case openapi3.ParameterInHeader:
+			req.Header.Set(scheme.Name, key)
+		case openapi3.ParameterInQuery:
+			param := url.QueryEscape(scheme.Name) + "=" + url.QueryEscape(key)
+			if req.URL.RawQuery == "" {
+				req.URL.RawQuery = param
+			} else {
+				req.URL.RawQuery += "&" + param
+			}
+		case openapi3.ParameterInCookie:
+			req.AddCookie(&http.Cookie{Name: scheme.Name, Value: key})
+		}
+	}
+}
+
+// convertRequestBody converts an OpenAPI request body to MCP arguments
+func (c *Converter) convertRequestBody(requestBody *openapi3.RequestBody) ([]mcp.ToolOption, error) {
+	args := []mcp.ToolOption{}
+
+	contentType := requestBodyContentType(requestBody)
+	mediaType := requestBody.Content.Get(contentType)
+	if mediaType != nil && isGraphQLBody(contentType, mediaType) {
+		return c.convertGraphQLBody(requestBody, contentType), nil
+	}
+	if mediaType == nil || mediaType.Schema == nil || mediaType.Schema.Value == nil {
+		return args, nil
+	}
+
+	schema := mediaType.Schema.Value
+	propertyOptions := []mcp.PropertyOption{}
+	c.warnUnsupported(schema)
+
+	// Read-only properties are set by the server and can't be sent
+	c.inRequestBody = true
+	defer func() { c.inRequestBody = false }()
+
+	description := requestBody.Description
+	if contentType == contentTypeNDJSON {
+		description = appendDescription(description, "Each item is sent as one line of newline-delimited JSON.")
+	}
+	if examples := getMediaTypeExamples(mediaType); examples != "" {
+		description = appendDescription(description, examples)
+	}
+	if description != "" {
+		propertyOptions = append(propertyOptions, mcp.Description(description))
+	}
+
+	// A required body may be supplied as a body file instead
+	if requestBody.Required && c.options.BodyFileRoot == "" {
+		propertyOptions = append(propertyOptions, mcp.Required())
+	}
+
+	t := PropertyTypeObject
+	if contentType == contentTypeNDJSON && !schema.Type.Is("array") {
+		// The schema describes a single line, the body is a list of them
+		t = PropertyTypeArray
+		item := c.processSchemaProperty(schema, make(map[string]bool))
+		propertyOptions = append(propertyOptions, mcp.Items(item))
+	} else if schema.Type.Is("array") && schema.Items != nil && schema.Items.Value != nil {
+		t = PropertyTypeArray
+		item := c.processSchemaItems(schema.Items.Value, make(map[string]bool))
+		propertyOptions = append(propertyOptions, mcp.Items(item))
+	} else if schema.Type.Is("string") {
+		t = PropertyTypeString
+	} else if schema.Type.Is("integer") {
+		t = PropertyTypeInteger
+	} else if schema.Type.Is("number") {
+		t = PropertyTypeNumber
+	} else if schema.Type.Is("boolean") {
+		t = PropertyTypeBoolean
+	} else if len(schema.Properties) > 0 {
+		obj := c.processSchemaProperties(schema, make(map[string]bool))
+		propertyOptions = append(propertyOptions, mcp.Properties(obj))
+		if required := c.requiredProperties(schema); len(required) > 0 {
+			propertyOptions = append(propertyOptions, func(m map[string]interface{}) {
+				m["required"] = required
+			})
+		}
+	} else if c.options.FlattenAllOf && len(schema.AllOf) > 0 {
+		// Object composed with allOf, flattened into a single object
+		merged := c.processSchemaProperty(schema, make(map[string]bool))
+		if properties, ok := merged["properties"].(map[string]interface{}); ok {
+			propertyOptions = append(propertyOptions, mcp.Properties(properties))
+		} else {
+			propertyOptions = append(propertyOptions, c.additionalPropertiesOption(schema))
+		}
+		if required, ok := merged["required"].([]string); ok {
+			propertyOptions = append(propertyOptions, func(m map[string]interface{}) {
+				m["required"] = required
+			})
+		}
+	} else {
+		// Free-form or loosely typed object, accept any JSON value
+		propertyOptions = append(propertyOptions, c.additionalPropertiesOption(schema))
+	}
+	propertyOptions = append(propertyOptions, constraintOptions(t, schema)...)
+	if patternProperties, ok := c.processPatternProperties(schema, make(map[string]bool)); ok && t == PropertyTypeObject {
+		propertyOptions = append(propertyOptions, func(m map[string]interface{}) {
+			m["patternProperties"] = patternProperties
+		})
+	}
+
+	args = append(args, c.createToolOption(t, "body", propertyOptions...))
+
+	if c.options.BodyFileRoot != "" {
+		args = append(args, mcp.WithString("openapi|body_file",
+			mcp.Description("Path of a local file, relative to the body file root, streamed as the request body instead of body")))
+	}
+
+	// Only offer a content type selector when several media types genuinely exist
+	if len(requestBody.Content) > 1 {
+		contentTypes := make([]string, 0, len(requestBody.Content))
+		for ct := range requestBody.Content {
+			contentTypes = append(contentTypes, ct)
+		}
+		sort.Strings(contentTypes)
+		args = append(args, mcp.WithString("openapi|content_type",
+			mcp.Description(fmt.Sprintf("Content type used to send the body, the body schema describes %s", contentType)),
+			mcp.DefaultString(contentType),
+			mcp.Enum(contentTypes...)))
+	}
+
+	return args, nil
+}
+
+// defaultExample returns the value of the example flagged with x-default or named default,
+// falling back to the only example when there is a single one
+func defaultExample(namedExamples openapi3.Examples) (any, bool) {
+	var fallback any
+	for name, exampleRef := range namedExamples {
+		if exampleRef == nil || exampleRef.Value == nil || exampleRef.Value.Value == nil {
+			continue
+		}
+		if flagged, _ := getBoolExtension(exampleRef.Value.Extensions, "x-default"); flagged || name == "default" {
+			return exampleRef.Value.Value, true
+		}
+		fallback = exampleRef.Value.Value
+	}
+	if len(namedExamples) == 1 && fallback != nil {
+		return fallback, true
+	}
+	return nil, false
+}
+
+// getMediaTypeExamples describes the examples of a media type
+func getMediaTypeExamples(mediaType *openapi3.MediaType) string {
+	if mediaType.Example != nil {
+		str, err := json.Marshal(mediaType.Example)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("Example: %s", str)
+	}
+	if examples := describeExamples(mediaType.Examples); examples != "" {
+		return examples
+	}
+	if mediaType.Schema != nil && mediaType.Schema.Value != nil {
+		return getSchemaExamples(mediaType.Schema.Value)
+	}
+	return ""
 }
 
-// convertSecurityRequirements converts OpenAPI security requirements to MCP arguments
-func (c *Converter) convertSecurityRequirements(securityRequirements openapi3.SecurityRequirements) []mcp.ToolOption {
-	args := []mcp.ToolOption{}
+// getSchemaExamples describes the examples of a body schema, such as a referenced component,
+// used when the media type documents none itself
+func getSchemaExamples(schema *openapi3.Schema) string {
+	if schema.Example != nil {
+		str, err := json.Marshal(schema.Example)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("Example: %s", str)
+	}
 
-	// Get security definitions from the document
-	components := c.parser.GetDocument().Components
-	if components == nil {
-		return nil
+	values, _ := schema.Extensions["examples"].([]any)
+	examples := make([]string, 0, len(values))
+	for _, value := range values {
+		str, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		examples = append(examples, fmt.Sprintf("- %s", str))
 	}
-	securitySchemes := components.SecuritySchemes
-	if len(securitySchemes) == 0 {
-		return nil
+	if len(examples) == 0 {
+		return ""
 	}
+	return "Examples:\n" + strings.Join(examples, "\n")
+}
 
-	// Process each security requirement
-	for _, requirement := range securityRequirements {
-		for schemeName, scopes := range requirement {
-			schemeRef := securitySchemes[schemeName]
-			if schemeRef == nil || schemeRef.Value == nil {
-				continue
-			}
+// describeExamples describes named examples sorted by name
+func describeExamples(namedExamples openapi3.Examples) string {
+	names := make([]string, 0, len(namedExamples))
+	for name, exampleRef := range namedExamples {
+		if exampleRef != nil && exampleRef.Value != nil && exampleRef.Value.Value != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
 
-			scheme := schemeRef.Value
-			switch scheme.Type {
-			case "apiKey":
-				args = append(args, mcp.WithString("openapi|auth_"+schemeName,
-					mcp.Description(fmt.Sprintf("API Key for %s authentication (in %s named '%s')",
-						schemeName, scheme.In, scheme.Name)),
-					mcp.Required()))
-			case "http":
-				switch scheme.Scheme {
-				case "basic":
-					args = append(args, mcp.WithString("openapi|auth_username",
-						mcp.Description("Username for Basic authentication"),
-						mcp.Required()))
-					args = append(args, mcp.WithString("openapi|auth_password",
-						mcp.Description("Password for Basic authentication"),
-						mcp.Required()))
-				case "bearer":
-					args = append(args, mcp.WithString("openapi|auth_token",
-						mcp.Description("Bearer token for authentication"),
-						mcp.Required()))
-				}
-			case "oauth2":
-				if len(scopes) > 0 {
-					scopeDesc := "OAuth2 token with scopes: " + strings.Join(scopes, ", ")
-					args = append(args, mcp.WithString("openapi|auth_oauth2_token",
-						mcp.Description(scopeDesc),
-						mcp.Required()))
-				} else {
-					args = append(args, mcp.WithString("openapi|auth_oauth2_token",
-						mcp.Description("OAuth2 token for authentication"),
-						mcp.Required()))
-				}
-			}
+	examples := make([]string, 0, len(names))
+	for _, name := range names {
+		example := namedExamples[name].Value
+		str, err := json.Marshal(example.Value)
+		if err != nil {
+			continue
+		}
+		if example.Summary != "" {
+			examples = append(examples, fmt.Sprintf("- %s (%s): %s", name, example.Summary, str))
+		} else {
+			examples = append(examples, fmt.Sprintf("- %s: %s", name, str))
 		}
 	}
+	return "Examples:\n" + strings.Join(examples, "\n")
+}
 
-	return args
+const (
+	contentTypeJSON   = "application/json"
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeForm   = "application/x-www-form-urlencoded"
+)
+
+// isJSONContentType reports whether a response content type carries JSON,
+// either by the built-in detection or by being one of the configured JSON content types
+func (c *Converter) isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if isJSONMediaType(mediaType) {
+		return true
+	}
+	return slices.ContainsFunc(c.options.JSONContentTypes, func(jsonType string) bool {
+		return strings.EqualFold(jsonType, mediaType)
+	})
 }
 
-// convertRequestBody converts an OpenAPI request body to MCP arguments
-func (c *Converter) convertRequestBody(requestBody *openapi3.RequestBody) ([]mcp.ToolOption, error) {
-	args := []mcp.ToolOption{}
+// isJSONMediaType reports whether a media type carries JSON, including structured syntax suffixes like +json
+func isJSONMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	return mediaType == contentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
 
-	for _, mediaType := range requestBody.Content {
-		if mediaType.Schema == nil || mediaType.Schema.Value == nil {
-			continue
-		}
+// requestBodyContentType returns the media type used to send a request body,
+// preferring JSON when several are declared
+func requestBodyContentType(requestBody *openapi3.RequestBody) string {
+	if requestBody == nil || len(requestBody.Content) == 0 {
+		return contentTypeJSON
+	}
+	if _, ok := requestBody.Content[contentTypeJSON]; ok {
+		return contentTypeJSON
+	}
 
-		schema := mediaType.Schema.Value
-		propertyOptions := []mcp.PropertyOption{}
+	contentTypes := make([]string, 0, len(requestBody.Content))
+	for contentType := range requestBody.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	return contentTypes[0]
+}
 
-		if requestBody.Description != "" {
-			propertyOptions = append(propertyOptions, mcp.Description(requestBody.Description))
+// encodeBody encodes the request body for the media type and returns the Content-Type to send,
+// text bodies of non JSON media types are sent as is and other bodies as JSON
+func encodeBody(contentType string, body any, encoding map[string]*openapi3.Encoding, pretty bool) ([]byte, string, error) {
+	if isMultipart(contentType) {
+		return marshalMultipart(contentType, body, encoding)
+	}
+	if contentType == contentTypeNDJSON || isJSONMediaType(contentType) {
+		data, err := marshalBody(contentType, body, pretty)
+		return data, contentType, err
+	}
+	if object, ok := body.(map[string]any); ok && contentType == contentTypeForm {
+		values, err := formValues(object)
+		if err != nil {
+			return nil, "", err
 		}
+		return []byte(values.Encode()), contentType, nil
+	}
+	if text, ok := body.(string); ok && contentType != "" {
+		return []byte(text), contentType, nil
+	}
+	data, err := marshalBody(contentTypeJSON, body, pretty)
+	return data, contentTypeJSON, err
+}
 
-		if requestBody.Required {
-			propertyOptions = append(propertyOptions, mcp.Required())
+// formValues encodes form fields, arrays as repeated fields and objects as JSON
+func formValues(fields map[string]any) (url.Values, error) {
+	values := url.Values{}
+	for key, value := range fields {
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
 		}
-
-		t := PropertyTypeObject
-		if schema.Type != nil {
-			if schema.Type.Is("array") && schema.Items != nil && schema.Items.Value != nil {
-				t = PropertyTypeArray
-				item := c.processSchemaItems(schema.Items.Value, make(map[string]bool))
-				propertyOptions = append(propertyOptions, mcp.Items(item))
-			} else if schema.Type.Is("object") || len(schema.Properties) > 0 {
-				obj := c.processSchemaProperties(schema, make(map[string]bool))
-				propertyOptions = append(propertyOptions, mcp.Properties(obj))
-			} else if schema.Type.Is("string") {
-				t = PropertyTypeString
-			} else if schema.Type.Is("integer") {
-				t = PropertyTypeInteger
-			} else if schema.Type.Is("number") {
-				t = PropertyTypeNumber
-			} else if schema.Type.Is("boolean") {
-				t = PropertyTypeBoolean
+		for _, item := range items {
+			if object, ok := item.(map[string]any); ok {
+				data, err := json.Marshal(object)
+				if err != nil {
+					return nil, err
+				}
+				values.Add(key, string(data))
+				continue
 			}
+			values.Add(key, formatValue(item))
 		}
+	}
+	return values, nil
+}
 
-		// Add content type as part of the parameter name
-		args = append(args, c.createToolOption(t, "body", propertyOptions...))
+// marshalBody encodes the request body for the given content type
+func marshalBody(contentType string, body any, pretty bool) ([]byte, error) {
+	items, ok := body.([]any)
+	if contentType != contentTypeNDJSON || !ok {
+		if pretty {
+			return json.MarshalIndent(body, "", "  ")
+		}
+		return json.Marshal(body)
 	}
 
-	return args, nil
+	var buf bytes.Buffer
+	for _, item := range items {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
 }
 
 type propertyType string
@@ -519,8 +2296,8 @@ const (
 	PropertyTypeArray   propertyType = "array"
 )
 
-// convertParameters converts OpenAPI parameters to MCP arguments
-func (c *Converter) convertParameters(parameters openapi3.Parameters) ([]mcp.ToolOption, error) {
+// convertParameters converts OpenAPI parameters to MCP arguments, parameters listed in optional are never required
+func (c *Converter) convertParameters(parameters openapi3.Parameters, optional []string) ([]mcp.ToolOption, error) {
 	args := []mcp.ToolOption{}
 
 	for _, paramRef := range parameters {
@@ -529,27 +2306,38 @@ func (c *Converter) convertParameters(parameters openapi3.Parameters) ([]mcp.Too
 			continue
 		}
 
-		propertyOptions := []mcp.PropertyOption{
-			mcp.Description(param.Description),
-		}
+		key := param.In + "|" + param.Name
+		description := param.Description
+		propertyOptions := []mcp.PropertyOption{}
 
-		if param.Required {
+		// Parameters listed as optional, by name or as in|name, are supplied by the deployment
+		if param.Required && !slices.Contains(optional, param.Name) && !slices.Contains(optional, key) {
 			propertyOptions = append(propertyOptions, mcp.Required())
 		}
 
 		t := PropertyTypeString
-		if param.Schema != nil && param.Schema.Value != nil {
-			schema := param.Schema.Value
+		if schema, contentType := parameterSchema(param); schema != nil {
+			c.warnUnsupported(schema)
+			if contentType != "" {
+				description = appendDescription(description, fmt.Sprintf("Sent encoded as %s.", contentType))
+			}
 
 			// Determine property type and add specific options
 			if schema.Type.Is("array") && schema.Items != nil && schema.Items.Value != nil {
 				t = PropertyTypeArray
 				item := c.processSchemaItems(schema.Items.Value, make(map[string]bool))
 				propertyOptions = append(propertyOptions, mcp.Items(item))
+				if param.In == openapi3.ParameterInQuery && schema.Items.Value.Type.Is("object") {
+					description = appendDescription(description, "Each object is sent JSON-encoded in the query string.")
+				}
 			} else if schema.Type.Is("object") && len(schema.Properties) > 0 {
 				t = PropertyTypeObject
 				obj := c.processSchemaProperties(schema, make(map[string]bool))
 				propertyOptions = append(propertyOptions, mcp.Properties(obj))
+			} else if schema.Type.Is("object") && contentType != "" {
+				// Content-typed objects are encoded whole, so free-form ones are accepted as objects
+				t = PropertyTypeObject
+				propertyOptions = append(propertyOptions, c.additionalPropertiesOption(schema))
 			} else if schema.Type.Is("integer") {
 				t = PropertyTypeInteger
 			} else if schema.Type.Is("number") {
@@ -562,6 +2350,11 @@ func (c *Converter) convertParameters(parameters openapi3.Parameters) ([]mcp.Too
 			if len(schema.Enum) > 0 {
 				enumValues := make([]string, 0, len(schema.Enum))
 				for _, val := range schema.Enum {
+					if val == nil {
+						// Null members of nullable enums can't be sent as a value, the parameter is omitted instead
+						description = appendDescription(description, "Omit this parameter for a null value.")
+						continue
+					}
 					if strVal, ok := val.(string); ok {
 						enumValues = append(enumValues, strVal)
 					} else {
@@ -574,11 +2367,27 @@ func (c *Converter) convertParameters(parameters openapi3.Parameters) ([]mcp.Too
 				}
 			}
 
-			// Add example if present
-			if schema.Example != nil {
+			// Add the default if present, falling back to the example
+			if schema.Default != nil {
+				propertyOptions = append(propertyOptions, func(property map[string]any) {
+					property["default"] = schema.Default
+				})
+				if param.In == openapi3.ParameterInQuery || param.In == openapi3.ParameterInHeader {
+					description = appendDescription(description, fmt.Sprintf("Defaults to %s when omitted.", formatValue(schema.Default)))
+				}
+			} else if schema.Example != nil {
 				propertyOptions = append(propertyOptions, mcp.DefaultString(fmt.Sprintf("%v", schema.Example)))
+			} else if example, ok := defaultExample(param.Examples); ok {
+				propertyOptions = append(propertyOptions, mcp.DefaultString(formatValue(example)))
 			}
+
+			propertyOptions = append(propertyOptions, constraintOptions(t, schema)...)
+		}
+
+		if examples := describeExamples(param.Examples); examples != "" {
+			description = appendDescription(description, examples)
 		}
+		propertyOptions = append(propertyOptions, mcp.Description(description))
 
 		// Add the parameter based on its type
 		if param.In == "body" {
@@ -593,8 +2402,15 @@ func (c *Converter) convertParameters(parameters openapi3.Parameters) ([]mcp.Too
 
 // processSchemaItems processes schema items for array types
 func (c *Converter) processSchemaItems(schema *openapi3.Schema, visited map[string]bool) map[string]interface{} {
+	if !c.enterSchema(schema) {
+		return c.truncatedSchema(schema)
+	}
+	defer c.leaveSchema()
+
 	item := make(map[string]interface{})
 
+	c.warnUnsupported(schema)
+
 	if schema.Type != nil {
 		item["type"] = schema.Type
 	}
@@ -607,8 +2423,8 @@ func (c *Converter) processSchemaItems(schema *openapi3.Schema, visited map[stri
 	if len(schema.Properties) > 0 {
 		properties := make(map[string]interface{})
 		for propName, propRef := range schema.Properties {
-			if propRef.Value != nil {
-				properties[propName] = c.processSchemaProperty(propRef.Value, visited)
+			if propRef.Value != nil && !c.excludedProperty(propRef.Value) {
+				properties[propName] = c.processSchemaRef(propRef, visited)
 			}
 		}
 		item["properties"] = properties
@@ -616,7 +2432,7 @@ func (c *Converter) processSchemaItems(schema *openapi3.Schema, visited map[stri
 
 	// Handle reference if this is a reference to another schema
 	if schema.Items != nil && schema.Items.Value != nil {
-		item["items"] = c.processSchemaItems(schema.Items.Value, visited)
+		item["items"] = c.describeRef(c.processSchemaItems(schema.Items.Value, visited), schema.Items)
 	}
 
 	return item
@@ -627,18 +2443,96 @@ func (c *Converter) processSchemaProperties(schema *openapi3.Schema, visited map
 	obj := make(map[string]interface{})
 
 	for propName, propRef := range schema.Properties {
-		if propRef.Value != nil {
-			obj[propName] = c.processSchemaProperty(propRef.Value, visited)
+		if propRef.Value != nil && !c.excludedProperty(propRef.Value) {
+			obj[propName] = c.processSchemaRef(propRef, visited)
 		}
 	}
 
 	return obj
 }
 
+// excludedProperty reports whether a property is left out of the schema being converted
+func (c *Converter) excludedProperty(schema *openapi3.Schema) bool {
+	return c.inRequestBody && schema.ReadOnly
+}
+
+// requiredProperties returns the required properties of an object schema that are not excluded,
+// so clients are never asked for a property they can't set
+func (c *Converter) requiredProperties(schema *openapi3.Schema) []string {
+	required := make([]string, 0, len(schema.Required))
+	for _, name := range schema.Required {
+		if propRef := schema.Properties[name]; propRef != nil && propRef.Value != nil && c.excludedProperty(propRef.Value) {
+			continue
+		}
+		required = append(required, name)
+	}
+	return required
+}
+
+// processSchemaRef processes a schema reference, naming the referenced component in the description
+func (c *Converter) processSchemaRef(schemaRef *openapi3.SchemaRef, visited map[string]bool) map[string]interface{} {
+	return c.describeRef(c.processSchemaProperty(schemaRef.Value, visited), schemaRef)
+}
+
+// describeRef prefixes the description of a processed schema with the name of the component it references,
+// giving the model the semantic type of otherwise anonymous objects
+func (c *Converter) describeRef(property map[string]interface{}, schemaRef *openapi3.SchemaRef) map[string]interface{} {
+	if !c.options.IncludeRefNames || schemaRef.Ref == "" {
+		return property
+	}
+	name := schemaRef.Ref[strings.LastIndex(schemaRef.Ref, "/")+1:]
+	if description, ok := property["description"].(string); ok && description != "" {
+		property["description"] = name + ": " + description
+	} else {
+		property["description"] = name
+	}
+	return property
+}
+
+// enterSchema descends into a schema, reporting false when it lies beyond the maximum depth and
+// nests further schemas, scalar schemas are always kept since they can't recurse
+func (c *Converter) enterSchema(schema *openapi3.Schema) bool {
+	if maxDepth := c.options.MaxSchemaDepth; maxDepth > 0 && c.schemaDepth >= maxDepth && hasNestedSchemas(schema) {
+		return false
+	}
+	c.schemaDepth++
+	return true
+}
+
+// hasNestedSchemas reports whether a schema contains subschemas
+func hasNestedSchemas(schema *openapi3.Schema) bool {
+	return len(schema.Properties) > 0 || schema.Items != nil || schema.Not != nil ||
+		len(schema.OneOf) > 0 || len(schema.AnyOf) > 0 || len(schema.AllOf) > 0 ||
+		schema.AdditionalProperties.Schema != nil
+}
+
+// leaveSchema returns from a schema entered with enterSchema
+func (c *Converter) leaveSchema() {
+	c.schemaDepth--
+}
+
+// truncatedSchema returns the marker replacing a schema nested beyond the maximum depth
+func (c *Converter) truncatedSchema(schema *openapi3.Schema) map[string]interface{} {
+	property := map[string]interface{}{
+		"description": fmt.Sprintf("Schema truncated at the maximum depth of %d", c.options.MaxSchemaDepth),
+	}
+	if schema.Type != nil {
+		property["type"] = schema.Type
+	}
+	return property
+}
+
 // processSchemaProperty processes a single schema property
 func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[string]bool) map[string]interface{} {
+	if !c.enterSchema(schema) {
+		return c.truncatedSchema(schema)
+	}
+	defer c.leaveSchema()
+
 	property := make(map[string]interface{})
 
+	c.warnUnsupported(schema)
+
 	// Check for circular references
 	if schema.Title != "" {
 		refKey := schema.Title
@@ -650,12 +2544,13 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 				"title":       refKey,
 			}
 		}
-		visited[refKey] = true
-		// Create a copy of the visited map to avoid cross-contamination between different branches
+		// Create a copy of the visited map to avoid cross-contamination between different branches,
+		// marking the parent's map would turn siblings sharing the schema into circular references
 		visitedCopy := make(map[string]bool)
 		for k, v := range visited {
 			visitedCopy[k] = v
 		}
+		visitedCopy[refKey] = true
 		visited = visitedCopy
 	}
 
@@ -688,7 +2583,7 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 		oneOf := make([]interface{}, 0, len(schema.OneOf))
 		for _, schemaRef := range schema.OneOf {
 			if schemaRef.Value != nil {
-				oneOf = append(oneOf, c.processSchemaProperty(schemaRef.Value, visited))
+				oneOf = append(oneOf, c.processSchemaRef(schemaRef, visited))
 			}
 		}
 		if len(oneOf) > 0 {
@@ -700,7 +2595,7 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 		anyOf := make([]interface{}, 0, len(schema.AnyOf))
 		for _, schemaRef := range schema.AnyOf {
 			if schemaRef.Value != nil {
-				anyOf = append(anyOf, c.processSchemaProperty(schemaRef.Value, visited))
+				anyOf = append(anyOf, c.processSchemaRef(schemaRef, visited))
 			}
 		}
 		if len(anyOf) > 0 {
@@ -708,14 +2603,15 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 		}
 	}
 
+	var allOf []interface{}
 	if len(schema.AllOf) > 0 {
-		allOf := make([]interface{}, 0, len(schema.AllOf))
+		allOf = make([]interface{}, 0, len(schema.AllOf))
 		for _, schemaRef := range schema.AllOf {
 			if schemaRef.Value != nil {
-				allOf = append(allOf, c.processSchemaProperty(schemaRef.Value, visited))
+				allOf = append(allOf, c.processSchemaRef(schemaRef, visited))
 			}
 		}
-		if len(allOf) > 0 {
+		if len(allOf) > 0 && !c.options.FlattenAllOf {
 			property["allOf"] = allOf
 		}
 	}
@@ -743,19 +2639,10 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 	if schema.UniqueItems {
 		property["uniqueItems"] = schema.UniqueItems
 	}
-	if schema.ExclusiveMin {
-		property["exclusiveMinimum"] = schema.ExclusiveMin
-	}
-	if schema.ExclusiveMax {
-		property["exclusiveMaximum"] = schema.ExclusiveMax
-	}
 
 	// Number validations
-	if schema.Min != nil {
-		property["minimum"] = *schema.Min
-	}
-	if schema.Max != nil {
-		property["maximum"] = *schema.Max
+	for keyword, value := range numberBounds(schema) {
+		property[keyword] = value
 	}
 	if schema.MultipleOf != nil {
 		property["multipleOf"] = *schema.MultipleOf
@@ -787,8 +2674,8 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 	if schema.MaxProps != nil {
 		property["maxProperties"] = *schema.MaxProps
 	}
-	if len(schema.Required) > 0 {
-		property["required"] = schema.Required
+	if required := c.requiredProperties(schema); len(required) > 0 {
+		property["required"] = required
 	}
 
 	// Handle AdditionalProperties
@@ -798,6 +2685,17 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 		property["additionalProperties"] = c.processSchemaProperty(schema.AdditionalProperties.Schema.Value, visited)
 	}
 
+	if patternProperties, ok := c.processPatternProperties(schema, visited); ok {
+		property["patternProperties"] = patternProperties
+	}
+
+	// Pass through conditional subschemas
+	for _, keyword := range []string{"if", "then", "else"} {
+		if conditional, ok := c.processRawSchema(schema.Extensions[keyword], visited); ok {
+			property[keyword] = conditional
+		}
+	}
+
 	// Handle discriminator
 	if schema.Discriminator != nil {
 		discriminator := make(map[string]interface{})
@@ -812,8 +2710,8 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 	if schema.Type != nil && schema.Type.Is("object") && len(schema.Properties) > 0 {
 		nestedProps := make(map[string]interface{})
 		for propName, propRef := range schema.Properties {
-			if propRef.Value != nil {
-				nestedProps[propName] = c.processSchemaProperty(propRef.Value, visited)
+			if propRef.Value != nil && !c.excludedProperty(propRef.Value) {
+				nestedProps[propName] = c.processSchemaRef(propRef, visited)
 			}
 		}
 		property["properties"] = nestedProps
@@ -821,7 +2719,7 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 
 	// Recursively process array items
 	if schema.Type != nil && schema.Type.Is("array") && schema.Items != nil && schema.Items.Value != nil {
-		property["items"] = c.processSchemaItems(schema.Items.Value, visited)
+		property["items"] = c.describeRef(c.processSchemaItems(schema.Items.Value, visited), schema.Items)
 	}
 
 	// Handle external docs if present
@@ -853,9 +2751,153 @@ func (c *Converter) processSchemaProperty(schema *openapi3.Schema, visited map[s
 		property["xml"] = xml
 	}
 
+	// Merge allOf branches last so the keywords of the schema itself take precedence
+	if c.options.FlattenAllOf {
+		for _, branch := range allOf {
+			mergeSchema(property, branch.(map[string]interface{}))
+		}
+	}
+
 	return property
 }
 
+// mergeSchema merges a processed allOf branch into the processed schema, properties and
+// required lists are combined and other keywords are only set when the schema lacks them
+func mergeSchema(property, branch map[string]interface{}) {
+	for key, value := range branch {
+		switch key {
+		case "properties":
+			properties, _ := property["properties"].(map[string]interface{})
+			if properties == nil {
+				properties = make(map[string]interface{})
+			}
+			for name, schema := range value.(map[string]interface{}) {
+				properties[name] = schema
+			}
+			property["properties"] = properties
+		case "required":
+			required, _ := property["required"].([]string)
+			for _, name := range value.([]string) {
+				if !slices.Contains(required, name) {
+					required = append(required, name)
+				}
+			}
+			property["required"] = required
+		default:
+			if _, ok := property[key]; !ok {
+				property[key] = value
+			}
+		}
+	}
+}
+
+// processRawSchema processes a schema kept as raw JSON, such as JSON Schema keywords unknown to the parser
+func (c *Converter) processRawSchema(raw any, visited map[string]bool) (map[string]interface{}, bool) {
+	rawSchema, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	jsonStr, err := json.Marshal(rawSchema)
+	if err != nil {
+		return nil, false
+	}
+	schema := openapi3.Schema{}
+	if err := json.Unmarshal(jsonStr, &schema); err != nil {
+		return nil, false
+	}
+	return c.processSchemaProperty(&schema, visited), true
+}
+
+// processPatternProperties processes the schemas of object keys matching a pattern
+func (c *Converter) processPatternProperties(schema *openapi3.Schema, visited map[string]bool) (map[string]interface{}, bool) {
+	rawPatterns, ok := schema.Extensions["patternProperties"].(map[string]interface{})
+	if !ok || len(rawPatterns) == 0 {
+		return nil, false
+	}
+	patternProperties := make(map[string]interface{}, len(rawPatterns))
+	for pattern, raw := range rawPatterns {
+		if property, ok := c.processRawSchema(raw, visited); ok {
+			patternProperties[pattern] = property
+		}
+	}
+	return patternProperties, true
+}
+
+// additionalPropertiesOption describes the extra keys accepted by a schema without declared properties
+func (c *Converter) additionalPropertiesOption(schema *openapi3.Schema) mcp.PropertyOption {
+	if schema.AdditionalProperties.Has != nil {
+		return mcp.AdditionalProperties(*schema.AdditionalProperties.Has)
+	}
+	if schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
+		return mcp.AdditionalProperties(c.processSchemaProperty(schema.AdditionalProperties.Schema.Value, make(map[string]bool)))
+	}
+	return mcp.AdditionalProperties(true)
+}
+
+// numberBounds returns the minimum and maximum keywords of a schema in the JSON Schema draft-06 form
+// expected by clients, where exclusive bounds are numbers replacing minimum and maximum
+// rather than the OpenAPI 3.0 booleans qualifying them
+func numberBounds(schema *openapi3.Schema) map[string]interface{} {
+	bounds := make(map[string]interface{})
+	if schema.Min != nil {
+		if schema.ExclusiveMin {
+			bounds["exclusiveMinimum"] = *schema.Min
+		} else {
+			bounds["minimum"] = *schema.Min
+		}
+	}
+	if schema.Max != nil {
+		if schema.ExclusiveMax {
+			bounds["exclusiveMaximum"] = *schema.Max
+		} else {
+			bounds["maximum"] = *schema.Max
+		}
+	}
+	return bounds
+}
+
+// constraintOptions returns the validation keywords of a schema that apply to a top-level argument
+func constraintOptions(t propertyType, schema *openapi3.Schema) []mcp.PropertyOption {
+	options := []mcp.PropertyOption{}
+
+	switch t {
+	case PropertyTypeString:
+		if schema.MinLength != 0 {
+			options = append(options, mcp.MinLength(int(schema.MinLength)))
+		}
+		if schema.MaxLength != nil {
+			options = append(options, mcp.MaxLength(int(*schema.MaxLength)))
+		}
+		if schema.Pattern != "" {
+			options = append(options, mcp.Pattern(schema.Pattern))
+		}
+	case PropertyTypeInteger, PropertyTypeNumber:
+		if bounds := numberBounds(schema); len(bounds) > 0 {
+			options = append(options, func(m map[string]interface{}) {
+				maps.Copy(m, bounds)
+			})
+		}
+		if schema.MultipleOf != nil {
+			multipleOf := *schema.MultipleOf
+			options = append(options, func(m map[string]interface{}) {
+				m["multipleOf"] = multipleOf
+			})
+		}
+	case PropertyTypeArray:
+		if schema.MinItems != 0 {
+			options = append(options, mcp.MinItems(int(schema.MinItems)))
+		}
+		if schema.MaxItems != nil {
+			options = append(options, mcp.MaxItems(int(*schema.MaxItems)))
+		}
+		if schema.UniqueItems {
+			options = append(options, mcp.UniqueItems(true))
+		}
+	}
+
+	return options
+}
+
 // createToolOption creates the appropriate tool option based on property type
 func (c *Converter) createToolOption(t propertyType, name string, options ...mcp.PropertyOption) mcp.ToolOption {
 	switch t {
@@ -876,6 +2918,88 @@ func (c *Converter) createToolOption(t propertyType, name string, options ...mcp
 	}
 }
 
+// getInstructions returns the server instructions describing the purpose of the API
+func getInstructions(info *openapi3.Info) string {
+	var parts []string
+
+	if info.Title != "" {
+		parts = append(parts, info.Title)
+	}
+	if info.Description != "" {
+		parts = append(parts, info.Description)
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// getAPIContext returns a short preamble describing the whole API
+func getAPIContext(doc *openapi3.T) string {
+	var parts []string
+
+	if doc.Info != nil {
+		if doc.Info.Title != "" {
+			parts = append(parts, "API: "+doc.Info.Title)
+		}
+		// Only the first paragraph to keep every tool description short
+		if description, _, _ := strings.Cut(strings.TrimSpace(doc.Info.Description), "\n\n"); description != "" {
+			parts = append(parts, description)
+		}
+		if doc.Info.Contact != nil {
+			if doc.Info.Contact.URL != "" {
+				parts = append(parts, "Contact: "+doc.Info.Contact.URL)
+			} else if doc.Info.Contact.Email != "" {
+				parts = append(parts, "Contact: "+doc.Info.Contact.Email)
+			}
+		}
+	}
+	if doc.ExternalDocs != nil && doc.ExternalDocs.URL != "" {
+		parts = append(parts, "Documentation: "+doc.ExternalDocs.URL)
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// appendDescription appends a paragraph to a description
+func appendDescription(description, paragraph string) string {
+	if description == "" {
+		return paragraph
+	}
+	return description + "\n\n" + paragraph
+}
+
+// requiresConfirmation reports whether an operation needs human approval,
+// the x-mcp-confirm extension overrides the default for destructive methods
+func requiresConfirmation(method string, operation *openapi3.Operation) bool {
+	if confirm, ok := getBoolExtension(operation.Extensions, "x-mcp-confirm"); ok {
+		return confirm
+	}
+	return strings.EqualFold(method, http.MethodDelete)
+}
+
+// getBoolExtension returns the boolean value of a specification extension
+func getBoolExtension(extensions map[string]any, name string) (bool, bool) {
+	value, ok := extensions[name].(bool)
+	return value, ok
+}
+
+// getStringExtension returns the string value of a specification extension
+func getStringExtension(extensions map[string]any, name string) (string, bool) {
+	value, ok := extensions[name].(string)
+	return value, ok
+}
+
+// getStringsExtension returns the string list value of a specification extension
+func getStringsExtension(extensions map[string]any, name string) []string {
+	values, _ := extensions[name].([]any)
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value, ok := value.(string); ok {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
 // getDescription returns a description for an operation
 func getDescription(operation *openapi3.Operation) string {
 	var parts []string
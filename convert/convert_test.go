@@ -0,0 +1,971 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// recordedRequest is what the upstream test server received
+type recordedRequest struct {
+	Method  string
+	Path    string
+	Query   string
+	Header  http.Header
+	Body    string
+	Host    string
+	Cookies []*http.Cookie
+}
+
+// newUpstream starts a server recording the last request it received
+func newUpstream(t *testing.T) (*httptest.Server, *recordedRequest) {
+	t.Helper()
+	recorded := &recordedRequest{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*recorded = recordedRequest{
+			Method:  r.Method,
+			Path:    r.URL.EscapedPath(),
+			Query:   r.URL.RawQuery,
+			Header:  r.Header.Clone(),
+			Body:    string(body),
+			Host:    r.Host,
+			Cookies: r.Cookies(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream, recorded
+}
+
+// convertSpec converts a JSON or YAML OpenAPI 3 document
+func convertSpec(t *testing.T, spec string, options Options) (*Converter, *server.MCPServer) {
+	t.Helper()
+	parser := NewParser()
+	if err := parser.Parse([]byte(spec)); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	converter := NewConverter(parser, options)
+	s, err := converter.Convert()
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	return converter, s
+}
+
+// callTool calls a tool through the MCP server, returning its text result or the error message
+func callTool(t *testing.T, s *server.MCPServer, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	request, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": name, "arguments": args},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch response := s.HandleMessage(context.Background(), request).(type) {
+	case mcp.JSONRPCResponse:
+		result, ok := response.Result.(mcp.CallToolResult)
+		if !ok {
+			t.Fatalf("unexpected result %T", response.Result)
+		}
+		var texts []string
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+		return strings.Join(texts, "\n"), true
+	case mcp.JSONRPCError:
+		return response.Error.Message, false
+	default:
+		t.Fatalf("unexpected response %T", response)
+		return "", false
+	}
+}
+
+const handlerSpec = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    basic: {type: http, scheme: basic}
+    key: {type: apiKey, in: header, name: X-Api-Key}
+paths:
+  /items/{id}:
+    get:
+      operationId: getItem
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+        - {name: tag, in: query, schema: {type: array, items: {type: string}}}
+        - {name: ids, in: query, explode: false, schema: {type: array, items: {type: integer}}}
+        - {name: q, in: query, schema: {type: string}}
+      responses: {"200": {description: ok}}
+  /bearer:
+    get:
+      operationId: bearerAuth
+      security: [{bearer: []}]
+      responses: {"200": {description: ok}}
+  /basic:
+    get:
+      operationId: basicAuth
+      security: [{basic: []}]
+      responses: {"200": {description: ok}}
+  /either:
+    get:
+      operationId: eitherAuth
+      security: [{bearer: []}, {key: []}]
+      responses: {"200": {description: ok}}
+  /items:
+    post:
+      operationId: createItem
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+                count: {type: integer}
+      responses: {"200": {description: ok}}
+  /bulk:
+    post:
+      operationId: bulk
+      requestBody:
+        content:
+          application/x-ndjson:
+            schema:
+              type: object
+              properties:
+                id: {type: integer}
+      responses: {"200": {description: ok}}
+`
+
+func TestHandlerPathSubstitution(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	_, s := convertSpec(t, handlerSpec, Options{})
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{id: "42", want: "/items/42"},
+		{id: "a b", want: "/items/a%20b"},
+		{id: "a/b", want: "/items/a%2Fb"},
+		{id: "50%", want: "/items/50%25"},
+	}
+	for _, tt := range tests {
+		if text, ok := callTool(t, s, "getItem", map[string]any{
+			"openapi|server_addr": upstream.URL,
+			"path|id":             tt.id,
+		}); !ok {
+			t.Fatalf("id %q: %s", tt.id, text)
+		}
+		if recorded.Path != tt.want {
+			t.Errorf("id %q: path = %q, want %q", tt.id, recorded.Path, tt.want)
+		}
+	}
+}
+
+func TestHandlerQueryEncoding(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	_, s := convertSpec(t, handlerSpec, Options{})
+
+	if text, ok := callTool(t, s, "getItem", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"path|id":             "1",
+		"query|tag":           []any{"a", "b c"},
+		"query|ids":           []any{1, 2},
+		"query|q":             "x&y=z",
+	}); !ok {
+		t.Fatal(text)
+	}
+	if want := "ids=1,2&q=x%26y%3Dz&tag=a&tag=b+c"; recorded.Query != want {
+		t.Errorf("query = %q, want %q", recorded.Query, want)
+	}
+}
+
+func TestDefaultsPrecedence(t *testing.T) {
+	spec := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      parameters:
+        - {name: version, in: query, schema: {type: string, default: spec}}
+        - {name: limit, in: query, schema: {type: string, default: spec}}
+        - {name: sort, in: query, schema: {type: string, default: spec}}
+        - {name: X-Version, in: header, schema: {type: string, default: spec}}
+        - {name: X-Limit, in: header, schema: {type: string, default: spec}}
+      responses: {"200": {description: ok}}
+`
+	tests := []struct {
+		name       string
+		args       map[string]any
+		wantQuery  string
+		wantHeader string
+	}{
+		{
+			name:       "options over spec defaults",
+			wantQuery:  "limit=spec&sort=option&version=option",
+			wantHeader: "option",
+		},
+		{
+			name:       "caller over options",
+			args:       map[string]any{"query|version": "caller", "query|sort": "", "header|X-Version": "caller"},
+			wantQuery:  "limit=spec&sort=&version=caller",
+			wantHeader: "caller",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, recorded := newUpstream(t)
+			_, s := convertSpec(t, spec, Options{
+				DefaultQuery:   map[string]string{"version": "option", "sort": "option"},
+				DefaultHeaders: map[string]string{"x-version": "option"},
+			})
+			args := map[string]any{"openapi|server_addr": upstream.URL}
+			maps.Copy(args, tt.args)
+			if text, ok := callTool(t, s, "listItems", args); !ok {
+				t.Fatal(text)
+			}
+			if recorded.Query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", recorded.Query, tt.wantQuery)
+			}
+			if got := recorded.Header.Get("X-Version"); got != tt.wantHeader {
+				t.Errorf("X-Version = %q, want %q", got, tt.wantHeader)
+			}
+			if got := recorded.Header.Get("X-Limit"); got != "spec" {
+				t.Errorf("X-Limit = %q, want the spec default", got)
+			}
+		})
+	}
+}
+
+func TestHandlerAuthHeaderSelection(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	_, s := convertSpec(t, handlerSpec, Options{})
+
+	tests := []struct {
+		name       string
+		tool       string
+		args       map[string]any
+		wantAuth   string
+		wantAPIKey string
+	}{
+		{
+			name:     "bearer",
+			tool:     "bearerAuth",
+			args:     map[string]any{"openapi|auth_token": "secret"},
+			wantAuth: "Bearer secret",
+		},
+		{
+			name:     "basic",
+			tool:     "basicAuth",
+			args:     map[string]any{"openapi|auth_username": "user", "openapi|auth_password": "pass"},
+			wantAuth: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:       "selected api key",
+			tool:       "eitherAuth",
+			args:       map[string]any{"openapi|auth_scheme": "key", "openapi|auth_key": "k1", "openapi|auth_token": "secret"},
+			wantAPIKey: "k1",
+		},
+		{
+			name:     "selected bearer",
+			tool:     "eitherAuth",
+			args:     map[string]any{"openapi|auth_scheme": "bearer", "openapi|auth_key": "k1", "openapi|auth_token": "secret"},
+			wantAuth: "Bearer secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["openapi|server_addr"] = upstream.URL
+			// Credentials of several schemes are held in maps, repeat to catch order dependence
+			for range 10 {
+				if text, ok := callTool(t, s, tt.tool, tt.args); !ok {
+					t.Fatal(text)
+				}
+				if got := recorded.Header.Get("Authorization"); got != tt.wantAuth {
+					t.Fatalf("Authorization = %q, want %q", got, tt.wantAuth)
+				}
+				if got := recorded.Header.Get("X-Api-Key"); got != tt.wantAPIKey {
+					t.Fatalf("X-Api-Key = %q, want %q", got, tt.wantAPIKey)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerAPIKeyLocation(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	_, s := convertSpec(t, `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    header: {type: apiKey, in: header, name: X-Api-Key}
+    query: {type: apiKey, in: query, name: api_key}
+    cookie: {type: apiKey, in: cookie, name: session}
+paths:
+  /keys:
+    get:
+      operationId: keys
+      security: [{header: [], query: [], cookie: []}]
+      parameters:
+        - {name: q, in: query, schema: {type: string}}
+      responses: {"200": {description: ok}}
+`, Options{})
+
+	if text, ok := callTool(t, s, "keys", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"openapi|auth_header": "h1",
+		"openapi|auth_query":  "q 1",
+		"openapi|auth_cookie": "c1",
+		"query|q":             "x",
+	}); !ok {
+		t.Fatal(text)
+	}
+	if got := recorded.Header.Get("X-Api-Key"); got != "h1" {
+		t.Errorf("X-Api-Key = %q, want h1", got)
+	}
+	if want := "q=x&api_key=q+1"; recorded.Query != want {
+		t.Errorf("query = %q, want %q", recorded.Query, want)
+	}
+	if len(recorded.Cookies) != 1 || recorded.Cookies[0].Name != "session" || recorded.Cookies[0].Value != "c1" {
+		t.Errorf("cookies = %v, want session=c1", recorded.Cookies)
+	}
+	if got := recorded.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+}
+
+func TestHandlerBodyMarshaling(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	_, s := convertSpec(t, handlerSpec, Options{})
+
+	if text, ok := callTool(t, s, "createItem", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"body":                map[string]any{"name": "a", "count": 2},
+	}); !ok {
+		t.Fatal(text)
+	}
+	if recorded.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", recorded.Method)
+	}
+	if got := recorded.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if want := `{"count":2,"name":"a"}`; recorded.Body != want {
+		t.Errorf("body = %s, want %s", recorded.Body, want)
+	}
+
+	if text, ok := callTool(t, s, "bulk", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"body":                []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+	}); !ok {
+		t.Fatal(text)
+	}
+	if got := recorded.Header.Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+	}
+	if want := "{\"id\":1}\n{\"id\":2}\n"; recorded.Body != want {
+		t.Errorf("body = %q, want %q", recorded.Body, want)
+	}
+}
+
+func TestDuplicateParametersFirstWins(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	converter, s := convertSpec(t, `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /search:
+    get:
+      operationId: search
+      parameters:
+        - {name: tag, in: query, explode: false, schema: {type: array, items: {type: string}}}
+        - {name: tag, in: query, schema: {type: array, items: {type: string}}}
+        - {name: limit, in: query, schema: {type: integer, default: 10}}
+        - {name: limit, in: query, schema: {type: integer, default: 50}}
+        - {name: tag, in: header, schema: {type: string}}
+      responses: {"200": {description: ok}}
+`, Options{})
+
+	warnings := converter.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("warnings = %v, want 2", warnings)
+	}
+	if want := `GET /search: duplicate query parameter "tag" was ignored`; warnings[0].String() != want {
+		t.Errorf("warning = %q, want %q", warnings[0].String(), want)
+	}
+	if stats := converter.Stats(); stats.Warnings != 2 {
+		t.Errorf("stats warnings = %d, want 2", stats.Warnings)
+	}
+
+	if text, ok := callTool(t, s, "search", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"query|tag":           []any{"a", "b"},
+		"header|tag":          "h",
+	}); !ok {
+		t.Fatal(text)
+	}
+	if want := "limit=10&tag=a,b"; recorded.Query != want {
+		t.Errorf("query = %q, want %q", recorded.Query, want)
+	}
+	if got := recorded.Header.Get("Tag"); got != "h" {
+		t.Errorf("tag header = %q, want h", got)
+	}
+}
+
+func TestTransportForceHTTP2(t *testing.T) {
+	var proto string
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+	}))
+	upstream.Config.Protocols = new(http.Protocols)
+	upstream.Config.Protocols.SetHTTP1(true)
+	upstream.Config.Protocols.SetUnencryptedHTTP2(true)
+	upstream.Start()
+	defer upstream.Close()
+
+	for _, tt := range []struct {
+		config TransportConfig
+		want   string
+	}{
+		{config: TransportConfig{}, want: "HTTP/1.1"},
+		{config: TransportConfig{ForceHTTP2: true}, want: "HTTP/2.0"},
+	} {
+		client := &http.Client{Transport: tt.config.newTransport()}
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if proto != tt.want {
+			t.Errorf("ForceHTTP2 %v: protocol = %s, want %s", tt.config.ForceHTTP2, proto, tt.want)
+		}
+	}
+}
+
+func TestReloadDuringCalls(t *testing.T) {
+	upstream, _ := newUpstream(t)
+	converter, s := convertSpec(t, handlerSpec, Options{})
+
+	done := make(chan struct{})
+	errs := make(chan string, 1)
+	go func() {
+		defer close(done)
+		for range 50 {
+			if text, ok := callTool(t, s, "eitherAuth", map[string]any{
+				"openapi|server_addr": upstream.URL,
+				"openapi|auth_scheme": "key",
+				"openapi|auth_key":    "k1",
+			}); !ok {
+				select {
+				case errs <- text:
+				default:
+				}
+				return
+			}
+		}
+	}()
+
+	for range 10 {
+		parser := NewParser()
+		if err := parser.Parse([]byte(handlerSpec)); err != nil {
+			t.Fatal(err)
+		}
+		if err := converter.Reload(parser); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := converter.Operation("eitherAuth"); !ok {
+			t.Fatal("operation of a reloaded tool is missing")
+		}
+	}
+	<-done
+	select {
+	case text := <-errs:
+		t.Fatal(text)
+	default:
+	}
+}
+
+func TestHandlerGraphQLOperationName(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	_, s := convertSpec(t, `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /graphql:
+    post:
+      operationId: graphql
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                query: {type: string}
+                variables: {type: object}
+                operationName: {type: string}
+      responses: {"200": {description: ok}}
+`, Options{})
+
+	if text, ok := callTool(t, s, "graphql", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"query":               "query A { a } query B { b }",
+		"variables":           map[string]any{"id": 1},
+		"operationName":       "B",
+	}); !ok {
+		t.Fatal(text)
+	}
+	if want := `{"operationName":"B","query":"query A { a } query B { b }","variables":{"id":1}}`; recorded.Body != want {
+		t.Errorf("body = %s, want %s", recorded.Body, want)
+	}
+}
+
+const crossHostSpec = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    key: {type: apiKey, in: header, name: X-Api-Key}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      security: [{bearer: [], key: []}]
+      responses: {"200": {description: ok}}
+`
+
+// crossHostArgs are credentials and a Host override that must not reach another host
+func crossHostArgs(serverURL string) map[string]any {
+	return map[string]any{
+		"openapi|server_addr": serverURL,
+		"openapi|auth_token":  "secret",
+		"openapi|auth_key":    "k1",
+		"header|Host":         "api.example.com",
+	}
+}
+
+// checkCredentialsDropped fails when a request sent to another host carries the credentials of crossHostArgs
+func checkCredentialsDropped(t *testing.T, recorded *recordedRequest, host string) {
+	t.Helper()
+	if got := recorded.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q sent to another host", got)
+	}
+	if got := recorded.Header.Get("X-Api-Key"); got != "" {
+		t.Errorf("X-Api-Key = %q sent to another host", got)
+	}
+	if recorded.Host != host {
+		t.Errorf("Host = %q, want %q", recorded.Host, host)
+	}
+}
+
+func TestPaginationCrossHostDropsCredentials(t *testing.T) {
+	other, otherRecorded := newUpstream(t)
+	var firstAuth, secondAuth string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			firstAuth = r.Header.Get("Authorization")
+			w.Header().Set("Link", `</items?page=2>; rel="next"`)
+		case "2":
+			secondAuth = r.Header.Get("Authorization")
+			w.Header().Set("Link", `<`+other.URL+`/items?page=3>; rel="next"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `[1]`)
+	}))
+	defer upstream.Close()
+
+	_, s := convertSpec(t, crossHostSpec, Options{Pagination: &PaginationConfig{MaxPages: 3}})
+	if text, ok := callTool(t, s, "listItems", crossHostArgs(upstream.URL)); !ok {
+		t.Fatal(text)
+	}
+	if firstAuth != "Bearer secret" || secondAuth != "Bearer secret" {
+		t.Errorf("same host pages Authorization = %q, %q, want the bearer token", firstAuth, secondAuth)
+	}
+	if otherRecorded.Method == "" {
+		t.Fatal("page on another host wasn't fetched")
+	}
+	checkCredentialsDropped(t, otherRecorded, strings.TrimPrefix(other.URL, "http://"))
+}
+
+func TestAsyncPollingCrossHostDropsCredentials(t *testing.T) {
+	other, otherRecorded := newUpstream(t)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Operation-Location", other.URL+"/status")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer upstream.Close()
+
+	_, s := convertSpec(t, crossHostSpec, Options{AsyncPolling: &AsyncPollingConfig{Interval: time.Millisecond}})
+	if text, ok := callTool(t, s, "listItems", crossHostArgs(upstream.URL)); !ok {
+		t.Fatal(text)
+	}
+	if otherRecorded.Path != "/status" {
+		t.Fatalf("status URL on another host wasn't polled, got path %q", otherRecorded.Path)
+	}
+	checkCredentialsDropped(t, otherRecorded, strings.TrimPrefix(other.URL, "http://"))
+}
+
+func TestRedirectCrossHostDropsCredentials(t *testing.T) {
+	other, otherRecorded := newUpstream(t)
+	var firstRecorded recordedRequest
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		firstRecorded = recordedRequest{Query: r.URL.RawQuery, Header: r.Header.Clone(), Cookies: r.Cookies()}
+		// The redirect keeps the query string, which carries the API key
+		http.Redirect(w, r, other.URL+"/landing?"+r.URL.RawQuery, http.StatusFound)
+	}))
+	defer upstream.Close()
+
+	spec := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    key: {type: apiKey, in: header, name: X-Api-Key}
+    query: {type: apiKey, in: query, name: api_key}
+    session: {type: apiKey, in: cookie, name: session}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      security: [{bearer: [], key: [], query: [], session: []}]
+      parameters:
+        - {name: page, in: query, schema: {type: string}}
+      responses: {"200": {description: ok}}
+`
+	_, s := convertSpec(t, spec, Options{})
+	args := crossHostArgs(upstream.URL)
+	args["openapi|auth_query"] = "q1"
+	args["openapi|auth_session"] = "s1"
+	args["query|page"] = "2"
+	if text, ok := callTool(t, s, "listItems", args); !ok {
+		t.Fatal(text)
+	}
+
+	if got := firstRecorded.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("first host Authorization = %q, want the bearer token", got)
+	}
+	if !strings.Contains(firstRecorded.Query, "api_key=q1") || len(firstRecorded.Cookies) != 1 {
+		t.Errorf("first host query = %q, cookies = %v, want the API keys", firstRecorded.Query, firstRecorded.Cookies)
+	}
+	if otherRecorded.Path != "/landing" {
+		t.Fatalf("redirect to another host wasn't followed, got path %q", otherRecorded.Path)
+	}
+	checkCredentialsDropped(t, otherRecorded, strings.TrimPrefix(other.URL, "http://"))
+	if otherRecorded.Query != "page=2" {
+		t.Errorf("query = %q sent to another host, want only page=2", otherRecorded.Query)
+	}
+	if len(otherRecorded.Cookies) != 0 {
+		t.Errorf("cookies = %v sent to another host", otherRecorded.Cookies)
+	}
+}
+
+func TestCheckCredentialsSuppliedElsewhere(t *testing.T) {
+	upstream, _ := newUpstream(t)
+	spec := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    header: {type: apiKey, in: header, name: X-Api-Key}
+    query: {type: apiKey, in: query, name: api_key}
+    cookie: {type: apiKey, in: cookie, name: session}
+paths:
+  /bearer:
+    get:
+      operationId: bearer
+      security: [{bearer: []}]
+      responses: {"200": {description: ok}}
+  /header:
+    get:
+      operationId: header
+      security: [{header: []}]
+      responses: {"200": {description: ok}}
+  /query:
+    get:
+      operationId: query
+      security: [{query: []}]
+      responses: {"200": {description: ok}}
+  /cookie:
+    get:
+      operationId: cookie
+      security: [{cookie: []}]
+      responses: {"200": {description: ok}}
+`
+
+	tests := []struct {
+		name    string
+		tool    string
+		options Options
+		args    map[string]any
+		wantErr string
+	}{
+		{name: "default api key header", tool: "header", options: Options{DefaultHeaders: map[string]string{"x-api-key": "k1"}}},
+		{name: "caller api key header", tool: "header", args: map[string]any{"header|X-API-KEY": "k1"}},
+		{name: "default Authorization for api key", tool: "header", options: Options{DefaultHeaders: map[string]string{"Authorization": "Bearer t"}}, wantErr: "missing credential openapi|auth_header"},
+		{name: "caller Authorization", tool: "bearer", args: map[string]any{"header|Authorization": "Bearer t"}},
+		{name: "default Authorization", tool: "bearer", options: Options{DefaultHeaders: map[string]string{"Authorization": "Bearer t"}}},
+		{name: "default query", tool: "query", options: Options{DefaultQuery: map[string]string{"api_key": "k1"}}},
+		{name: "caller query", tool: "query", args: map[string]any{"query|api_key": "k1"}},
+		{name: "caller cookie", tool: "cookie", args: map[string]any{"header|Cookie": "a=1; session=s1"}},
+		{name: "other cookie", tool: "cookie", args: map[string]any{"header|Cookie": "a=1"}, wantErr: "missing credential openapi|auth_cookie"},
+		{name: "missing", tool: "bearer", wantErr: "missing credential openapi|auth_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, s := convertSpec(t, spec, tt.options)
+			args := map[string]any{"openapi|server_addr": upstream.URL}
+			for key, value := range tt.args {
+				args[key] = value
+			}
+			text, ok := callTool(t, s, tt.tool, args)
+			switch {
+			case tt.wantErr == "" && !ok:
+				t.Errorf("unexpected error: %s", text)
+			case tt.wantErr != "" && (ok || !strings.Contains(text, tt.wantErr)):
+				t.Errorf("got %q, want error %q", text, tt.wantErr)
+			}
+		})
+	}
+}
+
+const pinnedServerSpec = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+servers:
+  - url: /api
+paths:
+  /pinned:
+    get:
+      operationId: pinned
+      x-mcp-server-url: PINNED
+      responses: {"200": {description: ok}}
+  /unpinned:
+    get:
+      operationId: unpinned
+      responses: {"200": {description: ok}}
+`
+
+func TestServerURLExtension(t *testing.T) {
+	pinned, pinnedRecorded := newUpstream(t)
+	base, baseRecorded := newUpstream(t)
+	spec := strings.ReplaceAll(pinnedServerSpec, "PINNED", pinned.URL+"/v2")
+	converter, s := convertSpec(t, spec, Options{BaseURL: base.URL})
+
+	for _, tool := range converter.Tools() {
+		_, hasServerAddr := tool.InputSchema.Properties["openapi|server_addr"]
+		if tool.Name == "pinned" && hasServerAddr {
+			t.Error("pinned operation exposes openapi|server_addr")
+		}
+	}
+
+	// The pinned URL replaces the relative spec server resolved against BaseURL and the server_addr argument
+	if text, ok := callTool(t, s, "pinned", map[string]any{"openapi|server_addr": base.URL}); !ok {
+		t.Fatal(text)
+	}
+	if pinnedRecorded.Path != "/v2/pinned" {
+		t.Errorf("pinned path = %q, want /v2/pinned", pinnedRecorded.Path)
+	}
+	if baseRecorded.Path != "" {
+		t.Errorf("pinned operation sent to the base URL at %q", baseRecorded.Path)
+	}
+
+	// Other operations keep resolving the spec servers against BaseURL
+	if text, ok := callTool(t, s, "unpinned", nil); !ok {
+		t.Fatal(text)
+	}
+	if baseRecorded.Path != "/api/unpinned" {
+		t.Errorf("unpinned path = %q, want /api/unpinned", baseRecorded.Path)
+	}
+}
+
+func TestServerURLExtensionInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		options Options
+		wantErr string
+	}{
+		{name: "relative", url: "/v2", options: Options{BaseURL: "http://example.com"}, wantErr: "not an absolute URL"},
+		{name: "not allowed", url: "http://pinned.example.com", options: Options{AllowedServers: []string{"http://example.com"}}, wantErr: "not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser()
+			if err := parser.Parse([]byte(strings.ReplaceAll(pinnedServerSpec, "PINNED", tt.url))); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewConverter(parser, tt.options).Convert()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResponseDescriptionCodes(t *testing.T) {
+	spec := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses:
+        "200":
+          description: ok
+          content:
+            text/plain: {schema: {type: string}}
+            application/json: {schema: {type: array, items: {type: integer}}}
+        "201": {description: created}
+        "404": {description: missing}
+        "500": {description: failed}
+        default: {description: error}
+`
+	tests := []struct {
+		name  string
+		codes []string
+		want  string
+	}{
+		{
+			name: "all",
+			want: `- status: 200, description: ok, content type: application/json, schema: {"items":{"type":"integer"},"type":"array"}, content type: text/plain, schema: {"type":"string"}
+
+- status: 201, description: created
+
+- status: 404, description: missing
+
+- status: 500, description: failed
+
+- status: default, description: error`,
+		},
+		{
+			name:  "classes and codes",
+			codes: []string{"2XX", "500"},
+			want: `- status: 200, description: ok, content type: application/json, schema: {"items":{"type":"integer"},"type":"array"}, content type: text/plain, schema: {"type":"string"}
+
+- status: 201, description: created
+
+- status: 500, description: failed`,
+		},
+		{
+			name:  "none matching",
+			codes: []string{"3XX"},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter, _ := convertSpec(t, spec, Options{ResponseCodes: tt.codes})
+			operation := converter.parser.GetPaths().Find("/items").Get
+			for range 5 {
+				if got := converter.generateResponseDescription(*operation.Responses); got != tt.want {
+					t.Fatalf("description =\n%s\nwant\n%s", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestExclusiveBounds(t *testing.T) {
+	converter, _ := convertSpec(t, `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    post:
+      operationId: createItem
+      parameters:
+        - {name: limit, in: query, schema: {type: integer, minimum: 0, exclusiveMinimum: true, maximum: 100}}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                ratio: {type: number, minimum: 0, maximum: 1, exclusiveMaximum: true}
+      responses: {"200": {description: ok}}
+`, Options{})
+
+	properties := converter.Tools()[0].InputSchema.Properties
+	data, err := json.Marshal(map[string]any{
+		"limit": properties["query|limit"],
+		"ratio": properties["body"].(map[string]any)["properties"].(map[string]any)["ratio"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bounds map[string]map[string]any
+	if err := json.Unmarshal(data, &bounds); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		property string
+		want     map[string]any
+	}{
+		{property: "limit", want: map[string]any{"exclusiveMinimum": 0.0, "maximum": 100.0}},
+		{property: "ratio", want: map[string]any{"minimum": 0.0, "exclusiveMaximum": 1.0}},
+	}
+	for _, tt := range tests {
+		schema := bounds[tt.property]
+		for _, keyword := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"} {
+			got, ok := schema[keyword]
+			want, wantOK := tt.want[keyword]
+			if ok != wantOK || got != want {
+				t.Errorf("%s: %s = %v, want %v", tt.property, keyword, got, want)
+			}
+		}
+	}
+}
+
+func TestGetArgsRejectsNonStrings(t *testing.T) {
+	for _, key := range []string{
+		"openapi|server_addr",
+		"openapi|auth_token",
+		"openapi|auth_username",
+		"openapi|auth_password",
+		"openapi|auth_oauth2_token",
+		"openapi|auth_oidc_token",
+		"openapi|auth_scheme",
+		"openapi|auth_key",
+		"openapi|content_type",
+		"openapi|body_file",
+		"openapi|result_path",
+	} {
+		_, err := getArgs(map[string]any{key: 1})
+		if want := key + " must be a string"; err == nil || err.Error() != want {
+			t.Errorf("%s: err = %v, want %q", key, err, want)
+		}
+	}
+
+	// The error is returned to the client instead of panicking the handler
+	_, s := convertSpec(t, handlerSpec, Options{})
+	if text, ok := callTool(t, s, "eitherAuth", map[string]any{"openapi|auth_scheme": 1}); ok || !strings.Contains(text, "must be a string") {
+		t.Errorf("got %q, want a must be a string error", text)
+	}
+}
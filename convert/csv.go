@@ -0,0 +1,59 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+const (
+	defaultCSVMinBodySize = 4096
+	defaultCSVSampleRows  = 10
+)
+
+// CSVSummaryConfig describes how large text/csv responses are summarized
+type CSVSummaryConfig struct {
+	// MinBodySize is the body size in bytes from which responses are summarized, defaults to 4096
+	MinBodySize int
+	// SampleRows is the number of rows kept after the header, defaults to 10
+	SampleRows int
+}
+
+// summarizeCSV replaces a large text/csv body with its header, row count and first rows,
+// bodies that are small or not valid CSV are returned unchanged
+func (c *Converter) summarizeCSV(contentType string, body []byte) []byte {
+	config := c.options.CSVSummary
+	minBodySize := config.MinBodySize
+	if minBodySize <= 0 {
+		minBodySize = defaultCSVMinBodySize
+	}
+	sampleRows := config.SampleRows
+	if sampleRows <= 0 {
+		sampleRows = defaultCSVSampleRows
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.EqualFold(mediaType, "text/csv") || len(body) < minBodySize {
+		return body
+	}
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil || len(records) == 0 {
+		return body
+	}
+
+	header, rows := records[0], records[1:]
+	sample := records[:min(len(records), sampleRows+1)]
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CSV summary: %d rows, %d columns, %d bytes\n", len(rows), len(header), len(body))
+	fmt.Fprintf(&buf, "columns: %s\n", strings.Join(header, ", "))
+	fmt.Fprintf(&buf, "first %d rows:\n", len(sample)-1)
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(sample); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
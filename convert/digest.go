@@ -0,0 +1,172 @@
+package convert
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// digestChallenge is a parsed WWW-Authenticate Digest challenge
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+// parseDigestChallenge parses the first Digest challenge of a response
+func parseDigestChallenge(headers []string) (*digestChallenge, bool) {
+	for _, header := range headers {
+		scheme, params, ok := strings.Cut(strings.TrimSpace(header), " ")
+		if !ok || !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		challenge := &digestChallenge{}
+		for key, value := range parseAuthParams(params) {
+			switch strings.ToLower(key) {
+			case "realm":
+				challenge.realm = value
+			case "nonce":
+				challenge.nonce = value
+			case "opaque":
+				challenge.opaque = value
+			case "algorithm":
+				challenge.algorithm = value
+			case "qop":
+				// Only the auth quality of protection is supported
+				for _, qop := range strings.Split(value, ",") {
+					if strings.TrimSpace(qop) == "auth" {
+						challenge.qop = "auth"
+					}
+				}
+			}
+		}
+		return challenge, challenge.nonce != ""
+	}
+	return nil, false
+}
+
+// parseAuthParams parses comma separated key=value pairs with optionally quoted values
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(key)
+		rest = strings.TrimSpace(rest)
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value = b.String()
+			s = rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// authorization computes the Authorization header answering the challenge
+func (d *digestChallenge) authorization(method, uri, username, password string) (string, error) {
+	var newHash func() hash.Hash
+	switch strings.ToUpper(d.algorithm) {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %s", d.algorithm)
+	}
+	digest := func(s string) string {
+		h := newHash()
+		io.WriteString(h, s)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	ha1 := digest(username + ":" + d.realm + ":" + password)
+	ha2 := digest(method + ":" + uri)
+
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, username, d.realm, d.nonce, uri)
+	if d.qop == "" {
+		header += fmt.Sprintf(`, response="%s"`, digest(ha1+":"+d.nonce+":"+ha2))
+	} else {
+		cnonce := make([]byte, 8)
+		if _, err := rand.Read(cnonce); err != nil {
+			return "", err
+		}
+		cnonceHex := hex.EncodeToString(cnonce)
+		const nc = "00000001"
+		response := digest(ha1 + ":" + d.nonce + ":" + nc + ":" + cnonceHex + ":" + d.qop + ":" + ha2)
+		header += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s", response="%s"`, d.qop, nc, cnonceHex, response)
+	}
+	if d.algorithm != "" {
+		header += ", algorithm=" + d.algorithm
+	}
+	if d.opaque != "" {
+		header += fmt.Sprintf(`, opaque="%s"`, d.opaque)
+	}
+	return header, nil
+}
+
+// doDigestAuth answers the Digest challenge of a 401 response by retrying the request with credentials,
+// the original response is returned when it carries no Digest challenge
+func doDigestAuth(client *http.Client, req *http.Request, resp *http.Response, username, password string) (*http.Response, error) {
+	challenge, ok := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+
+	auth, err := challenge.authorization(req.Method, req.URL.RequestURI(), username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", auth)
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return client.Do(retry)
+}
+
+// usesDigestAuth reports whether any of the security requirements uses HTTP Digest authentication
+func (s securitySchemes) usesDigestAuth(securityRequirements openapi3.SecurityRequirements) bool {
+	for _, requirement := range securityRequirements {
+		for schemeName := range requirement {
+			if scheme := s[schemeName]; scheme != nil && scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "digest") {
+				return true
+			}
+		}
+	}
+	return false
+}
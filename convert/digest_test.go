@@ -0,0 +1,117 @@
+package convert
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// digestServer answers requests without valid Digest credentials for user:pass with a challenge,
+// verifying the response hash as RFC 7616 describes
+func digestServer(t *testing.T, algorithm, qop string) *httptest.Server {
+	t.Helper()
+	const realm, nonce, opaque = "test@example.com", "dcd98b7102dd2f0e8b11d0f600bfb0c093", "5ccc069c403ebaf9f0171e9517f40e41"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		scheme, params, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		auth := parseAuthParams(params)
+
+		newHash := md5.New
+		if algorithm == "SHA-256" {
+			newHash = sha256.New
+		}
+		digest := func(s string) string {
+			h := newHash()
+			io.WriteString(h, s)
+			return hex.EncodeToString(h.Sum(nil))
+		}
+		ha1 := digest("user:" + realm + ":pass")
+		ha2 := digest(r.Method + ":" + r.URL.RequestURI())
+		want := digest(ha1 + ":" + nonce + ":" + ha2)
+		if qop != "" && scheme == "Digest" {
+			if auth["qop"] != "auth" || auth["nc"] != "00000001" || auth["cnonce"] == "" {
+				t.Errorf("qop = %q, nc = %q, cnonce = %q, want auth, 00000001 and a client nonce", auth["qop"], auth["nc"], auth["cnonce"])
+			}
+			want = digest(ha1 + ":" + nonce + ":" + auth["nc"] + ":" + auth["cnonce"] + ":auth:" + ha2)
+		}
+
+		if scheme == "Digest" && auth["username"] == "user" && auth["realm"] == realm && auth["nonce"] == nonce &&
+			auth["uri"] == r.URL.RequestURI() && auth["opaque"] == opaque && auth["algorithm"] == algorithm && auth["response"] == want {
+			io.WriteString(w, "authorized "+string(body))
+			return
+		}
+		challenge := `Digest realm="` + realm + `", nonce="` + nonce + `", opaque="` + opaque + `"`
+		if algorithm != "" {
+			challenge += ", algorithm=" + algorithm
+		}
+		if qop != "" {
+			challenge += `, qop="` + qop + `"`
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDoDigestAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		qop       string
+		password  string
+		want      int
+	}{
+		{name: "MD5 without qop", password: "pass", want: http.StatusOK},
+		{name: "explicit MD5 with qop", algorithm: "MD5", qop: "auth", password: "pass", want: http.StatusOK},
+		{name: "SHA-256 with qop", algorithm: "SHA-256", qop: "auth", password: "pass", want: http.StatusOK},
+		{name: "qop list", algorithm: "SHA-256", qop: "auth-int, auth", password: "pass", want: http.StatusOK},
+		{name: "wrong password", algorithm: "SHA-256", qop: "auth", password: "wrong", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := digestServer(t, tt.algorithm, tt.qop)
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/items/1?a=b%20c", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("unauthenticated status = %d, want 401", resp.StatusCode)
+			}
+
+			resp, err = doDigestAuth(server.Client(), req, resp, "user", tt.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusOK && string(body) != "authorized payload" {
+				t.Errorf("body = %q, want the request body replayed", body)
+			}
+		})
+	}
+}
+
+func TestDoDigestAuthUnsupportedAlgorithm(t *testing.T) {
+	server := digestServer(t, "SHA-512-256", "auth")
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doDigestAuth(server.Client(), req, resp, "user", "pass"); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
@@ -0,0 +1,28 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoDocument is returned when converting before an OpenAPI document is loaded
+	ErrNoDocument = errors.New("no OpenAPI document loaded")
+	// ErrNoInfo is returned when the OpenAPI document has no info section
+	ErrNoInfo = errors.New("no info found in OpenAPI document")
+)
+
+// OperationConvertError represents a failure to convert a single operation
+type OperationConvertError struct {
+	Path   string
+	Method string
+	Err    error
+}
+
+func (e *OperationConvertError) Error() string {
+	return fmt.Sprintf("failed to convert operation %s %s: %v", e.Method, e.Path, e.Err)
+}
+
+func (e *OperationConvertError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const contentTypeGraphQL = "application/graphql"
+
+// isGraphQLBody reports whether a request body carries a GraphQL query, either as an
+// application/graphql document or as a JSON object with query, variables and operationName members
+func isGraphQLBody(contentType string, mediaType *openapi3.MediaType) bool {
+	if contentType == contentTypeGraphQL {
+		return true
+	}
+	if !isJSONMediaType(contentType) || mediaType.Schema == nil || mediaType.Schema.Value == nil {
+		return false
+	}
+
+	properties := mediaType.Schema.Value.Properties
+	query := properties["query"]
+	if query == nil || query.Value == nil || !query.Value.Type.Is("string") {
+		return false
+	}
+	for name := range properties {
+		if name != "query" && name != "variables" && name != "operationName" {
+			return false
+		}
+	}
+	return true
+}
+
+// convertGraphQLBody converts a GraphQL request body to query, variables and operationName arguments
+func (c *Converter) convertGraphQLBody(requestBody *openapi3.RequestBody, contentType string) []mcp.ToolOption {
+	queryOptions := []mcp.PropertyOption{
+		mcp.Description(appendDescription(requestBody.Description, "GraphQL query document.")),
+	}
+	if requestBody.Required {
+		queryOptions = append(queryOptions, mcp.Required())
+	}
+	args := []mcp.ToolOption{mcp.WithString("query", queryOptions...)}
+
+	// application/graphql bodies only carry the query document
+	if contentType != contentTypeGraphQL {
+		args = append(args, mcp.WithObject("variables",
+			mcp.Description("Values of the variables used by the query"),
+			mcp.AdditionalProperties(true)))
+		args = append(args, mcp.WithString("operationName",
+			mcp.Description("Name of the operation to execute when the query document defines several")))
+	}
+	return args
+}
+
+// encodeGraphQLBody encodes a GraphQL query, its variables and operation name for the media type
+func encodeGraphQLBody(contentType, query string, variables map[string]any, operationName string, pretty bool) ([]byte, string, error) {
+	if contentType == contentTypeGraphQL {
+		if len(variables) > 0 {
+			return nil, "", errors.New("variables can't be sent with an application/graphql body")
+		}
+		if operationName != "" {
+			return nil, "", errors.New("operationName can't be sent with an application/graphql body")
+		}
+		return []byte(query), contentType, nil
+	}
+
+	body := map[string]any{"query": query}
+	if len(variables) > 0 {
+		body["variables"] = variables
+	}
+	if operationName != "" {
+		body["operationName"] = operationName
+	}
+	if !isJSONMediaType(contentType) {
+		contentType = contentTypeJSON
+	}
+	if pretty {
+		data, err := json.MarshalIndent(body, "", "  ")
+		return data, contentType, err
+	}
+	data, err := json.Marshal(body)
+	return data, contentType, err
+}
@@ -0,0 +1,41 @@
+package convert
+
+import (
+	"bytes"
+	"compress/gzip"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// defaultGzipMinBodySize is the compression threshold of operations enabling x-mcp-gzip
+// when no global threshold is configured
+const defaultGzipMinBodySize = 1024
+
+// gzipThreshold returns the body size from which request bodies of the operation are
+// gzip-compressed, zero meaning never. x-mcp-gzip enables or disables compression per operation.
+func (c *Converter) gzipThreshold(operation *openapi3.Operation) int {
+	enabled, ok := getBoolExtension(operation.Extensions, "x-mcp-gzip")
+	switch {
+	case !ok:
+		return c.options.GzipMinBodySize
+	case !enabled:
+		return 0
+	case c.options.GzipMinBodySize > 0:
+		return c.options.GzipMinBodySize
+	default:
+		return defaultGzipMinBodySize
+	}
+}
+
+// gzipBody compresses an encoded request body
+func gzipBody(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
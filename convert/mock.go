@@ -0,0 +1,169 @@
+package convert
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// mockResponse is the documented response returned in mock mode
+type mockResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// newMockResponse builds the mock response of an operation from its first documented successful response,
+// using the documented example or one generated from the schema
+func newMockResponse(operation *openapi3.Operation) (*mockResponse, error) {
+	mock := &mockResponse{statusCode: http.StatusOK, header: make(http.Header)}
+	if operation.Responses == nil {
+		return mock, nil
+	}
+
+	codes := make([]string, 0, operation.Responses.Len())
+	for code := range operation.Responses.Map() {
+		if matchResponseCode(code, []string{"2XX"}) {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return mock, nil
+	}
+	sort.Strings(codes)
+
+	// Status classes like 2XX sort after concrete codes and answer with their lowest code
+	if statusCode, err := strconv.Atoi(codes[0]); err == nil {
+		mock.statusCode = statusCode
+	}
+	response := operation.Responses.Value(codes[0]).Value
+	if response == nil || len(response.Content) == 0 {
+		return mock, nil
+	}
+
+	contentType := contentTypeJSON
+	if response.Content.Get(contentType) == nil {
+		contentTypes := make([]string, 0, len(response.Content))
+		for ct := range response.Content {
+			contentTypes = append(contentTypes, ct)
+		}
+		sort.Strings(contentTypes)
+		contentType = contentTypes[0]
+	}
+	mock.header.Set("Content-Type", contentType)
+
+	mediaType := response.Content.Get(contentType)
+	example := mediaType.Example
+	if example == nil && len(mediaType.Examples) > 0 {
+		names := make([]string, 0, len(mediaType.Examples))
+		for name := range mediaType.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if exampleRef := mediaType.Examples[name]; exampleRef != nil && exampleRef.Value != nil {
+				example = exampleRef.Value.Value
+				break
+			}
+		}
+	}
+	if example == nil && mediaType.Schema != nil {
+		example = mockSchemaValue(mediaType.Schema.Value, make(map[*openapi3.Schema]bool))
+	}
+	if example == nil {
+		return mock, nil
+	}
+
+	// Text examples of non JSON content are returned as is
+	if text, ok := example.(string); ok && !isJSONMediaType(contentType) {
+		mock.body = []byte(text)
+		return mock, nil
+	}
+	body, err := json.Marshal(example)
+	if err != nil {
+		return nil, err
+	}
+	mock.body = body
+	return mock, nil
+}
+
+// mockSchemaValue generates a value matching the schema, preferring its example, default and enum values
+func mockSchemaValue(schema *openapi3.Schema, visited map[*openapi3.Schema]bool) any {
+	if schema == nil || visited[schema] {
+		return nil
+	}
+	visited[schema] = true
+	defer delete(visited, schema)
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		if len(refs) == 0 {
+			continue
+		}
+		value := make(map[string]any)
+		for _, ref := range refs {
+			item := mockSchemaValue(ref.Value, visited)
+			object, ok := item.(map[string]any)
+			if !ok {
+				return item
+			}
+			for k, v := range object {
+				value[k] = v
+			}
+			// Only the first alternative of oneOf and anyOf is used
+			if len(schema.AllOf) == 0 {
+				break
+			}
+		}
+		return value
+	}
+
+	switch {
+	case schema.Type.Is("object") || len(schema.Properties) > 0:
+		value := make(map[string]any, len(schema.Properties))
+		for name, property := range schema.Properties {
+			if property.Value != nil {
+				value[name] = mockSchemaValue(property.Value, visited)
+			}
+		}
+		return value
+	case schema.Type.Is("array"):
+		if schema.Items == nil {
+			return []any{}
+		}
+		return []any{mockSchemaValue(schema.Items.Value, visited)}
+	case schema.Type.Is("string"):
+		switch schema.Format {
+		case "date":
+			return "2006-01-02"
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	case schema.Type.Is("integer"), schema.Type.Is("number"):
+		if schema.Min != nil {
+			return *schema.Min
+		}
+		return 0
+	case schema.Type.Is("boolean"):
+		return false
+	}
+	return nil
+}
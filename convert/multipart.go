@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// isMultipart reports whether a content type is a multipart media type such as multipart/mixed
+func isMultipart(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), "multipart/")
+}
+
+// marshalMultipart encodes an object body as a multipart body with one part per property,
+// returning the body and its content type carrying the boundary.
+//
+// Each part takes its content type from the encoding map, defaulting to text/plain for strings
+// and application/json otherwise. Parts with a multipart content type are encoded as nested
+// multipart bodies.
+func marshalMultipart(contentType string, body any, encoding map[string]*openapi3.Encoding) ([]byte, string, error) {
+	object, ok := body.(map[string]any)
+	if !ok {
+		return nil, "", fmt.Errorf("%s body must be an object", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := object[name]
+
+		explicitContentType := ""
+		if enc := encoding[name]; enc != nil && enc.ContentType != "" {
+			// The encoding may list several content types, the first one is used
+			explicitContentType = strings.TrimSpace(strings.Split(enc.ContentType, ",")[0])
+		}
+
+		// Form fields holding arrays are sent as one part per item unless their encoding says otherwise
+		items := []any{value}
+		if array, ok := value.([]any); ok && mediaType == "multipart/form-data" && explicitContentType == "" {
+			items = array
+		}
+
+		for _, item := range items {
+			if err := writeMultipartPart(writer, mediaType, name, explicitContentType, item); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), mime.FormatMediaType(mediaType, map[string]string{"boundary": writer.Boundary()}), nil
+}
+
+// writeMultipartPart writes a value as a part of a multipart body, using the content type
+// of its encoding or, without one, text/plain for strings and JSON for other values
+func writeMultipartPart(writer *multipart.Writer, mediaType, name, explicitContentType string, value any) error {
+	partContentType := explicitContentType
+	if partContentType == "" {
+		if _, ok := value.(string); ok {
+			partContentType = "text/plain"
+		} else {
+			partContentType = contentTypeJSON
+		}
+	}
+
+	var content []byte
+	var err error
+	switch {
+	case isMultipart(partContentType):
+		content, partContentType, err = marshalMultipart(partContentType, value, nil)
+		if err != nil {
+			return fmt.Errorf("part %s: %w", name, err)
+		}
+	case strings.HasPrefix(partContentType, "text/"):
+		if text, ok := value.(string); ok {
+			content = []byte(text)
+			break
+		}
+		fallthrough
+	default:
+		content, err = json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("part %s: %w", name, err)
+		}
+	}
+
+	disposition := "inline"
+	if mediaType == "multipart/form-data" {
+		disposition = "form-data"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"name": name}))
+	header.Set("Content-Type", partContentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryMargin is how long before its expiry an access token is refreshed,
+// so it doesn't expire while a request is in flight
+const oauth2ExpiryMargin = 30 * time.Second
+
+// oauth2Token exchanges a refresh token for access tokens, refreshing them once they expire
+type oauth2Token struct {
+	mu           sync.Mutex
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	refreshToken string
+	accessToken  string
+	// expiresAt is zero when the token endpoint didn't announce a lifetime,
+	// the access token is then used until the API rejects it
+	expiresAt time.Time
+}
+
+func newOAuth2Token(tokenURL, clientID, clientSecret, refreshToken string) *oauth2Token {
+	return &oauth2Token{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		refreshToken: refreshToken,
+	}
+}
+
+// get returns a valid access token, refreshing it first when there is none or it is about to expire
+func (t *oauth2Token) get(ctx context.Context, client *http.Client) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.accessToken != "" && (t.expiresAt.IsZero() || time.Until(t.expiresAt) > oauth2ExpiryMargin) {
+		return t.accessToken, nil
+	}
+	if err := t.refresh(ctx, client); err != nil {
+		return "", fmt.Errorf("failed to refresh OAuth2 access token: %w", err)
+	}
+	return t.accessToken, nil
+}
+
+// invalidate drops an access token rejected by the API, so the next call refreshes it
+func (t *oauth2Token) invalidate(accessToken string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.accessToken == accessToken {
+		t.accessToken = ""
+	}
+}
+
+// refresh requests a new access token with the refresh token grant, keeping the
+// refresh token returned by servers that rotate them
+func (t *oauth2Token) refresh(ctx context.Context, client *http.Client) error {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {t.refreshToken},
+	}
+	if t.clientID != "" {
+		form.Set("client_id", t.clientID)
+	}
+	if t.clientSecret != "" {
+		form.Set("client_secret", t.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeForm)
+	req.Header.Set("Accept", contentTypeJSON)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var token struct {
+		AccessToken      string  `json:"access_token"`
+		ExpiresIn        float64 `json:"expires_in"`
+		RefreshToken     string  `json:"refresh_token"`
+		Error            string  `json:"error"`
+		ErrorDescription string  `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, body)
+	}
+	if token.Error != "" {
+		if token.ErrorDescription != "" {
+			return fmt.Errorf("%s: %s", token.Error, token.ErrorDescription)
+		}
+		return errors.New(token.Error)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || token.AccessToken == "" {
+		return fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, body)
+	}
+
+	t.accessToken = token.AccessToken
+	t.expiresAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		t.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn * float64(time.Second)))
+	}
+	if token.RefreshToken != "" {
+		t.refreshToken = token.RefreshToken
+	}
+	return nil
+}
+
+// oauth2TokenURL returns the token URL of the authorization code flows declared by the document,
+// an empty string when there is none or they disagree
+func (c *Converter) oauth2TokenURL() string {
+	components := c.parser.GetDocument().Components
+	if components == nil {
+		return ""
+	}
+	var tokenURL string
+	for _, schemeRef := range components.SecuritySchemes {
+		scheme := schemeRef.Value
+		if scheme == nil || scheme.Type != "oauth2" || scheme.Flows == nil || scheme.Flows.AuthorizationCode == nil {
+			continue
+		}
+		flowURL := scheme.Flows.AuthorizationCode.TokenURL
+		if flowURL == "" {
+			continue
+		}
+		if tokenURL != "" && tokenURL != flowURL {
+			return ""
+		}
+		tokenURL = flowURL
+	}
+	return tokenURL
+}
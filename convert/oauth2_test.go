@@ -0,0 +1,165 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenEndpoint is an OAuth2 token endpoint issuing access token at-N and rotating refresh token rt-N
+// on its Nth refresh, recording the refresh tokens it received
+type tokenEndpoint struct {
+	mu            sync.Mutex
+	refreshes     int
+	refreshTokens []string
+}
+
+// newTokenEndpoint starts a token endpoint announcing access tokens valid for expiresIn seconds
+func newTokenEndpoint(t *testing.T, expiresIn int) (*httptest.Server, *tokenEndpoint) {
+	t.Helper()
+	endpoint := &tokenEndpoint{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "refresh_token" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		// Slow refreshes make concurrent callers overlap
+		time.Sleep(10 * time.Millisecond)
+		endpoint.mu.Lock()
+		endpoint.refreshes++
+		n := endpoint.refreshes
+		endpoint.refreshTokens = append(endpoint.refreshTokens, r.Form.Get("refresh_token"))
+		endpoint.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"at-%d","refresh_token":"rt-%d","expires_in":%d}`, n, n, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+	return server, endpoint
+}
+
+func (e *tokenEndpoint) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.refreshes
+}
+
+func TestOAuth2TokenRefreshesAfterExpiry(t *testing.T) {
+	server, endpoint := newTokenEndpoint(t, 3600)
+	token := newOAuth2Token(server.URL, "client", "", "rt-0")
+	ctx := context.Background()
+
+	for range 2 {
+		if got, err := token.get(ctx, server.Client()); err != nil || got != "at-1" {
+			t.Fatalf("get = %q, %v, want at-1", got, err)
+		}
+	}
+	if endpoint.count() != 1 {
+		t.Fatalf("refreshes = %d, want 1 while the token is valid", endpoint.count())
+	}
+
+	// Tokens expiring within the margin are refreshed before use
+	token.expiresAt = time.Now().Add(oauth2ExpiryMargin / 2)
+	if got, err := token.get(ctx, server.Client()); err != nil || got != "at-2" {
+		t.Fatalf("get = %q, %v, want at-2 after expiry", got, err)
+	}
+	if want := []string{"rt-0", "rt-1"}; fmt.Sprint(endpoint.refreshTokens) != fmt.Sprint(want) {
+		t.Errorf("refresh tokens sent = %v, want %v with the rotated one", endpoint.refreshTokens, want)
+	}
+}
+
+func TestOAuth2TokenInvalidate(t *testing.T) {
+	server, endpoint := newTokenEndpoint(t, 3600)
+	token := newOAuth2Token(server.URL, "", "", "rt-0")
+	ctx := context.Background()
+
+	first, _ := token.get(ctx, server.Client())
+	token.invalidate(first)
+	second, err := token.get(ctx, server.Client())
+	if err != nil || second != "at-2" {
+		t.Fatalf("get = %q, %v, want at-2 after invalidation", second, err)
+	}
+	// A late rejection of the old token keeps the refreshed one
+	token.invalidate(first)
+	if got, _ := token.get(ctx, server.Client()); got != "at-2" || endpoint.count() != 2 {
+		t.Errorf("get = %q after %d refreshes, want at-2 after 2", got, endpoint.count())
+	}
+}
+
+func TestOAuth2TokenConcurrentRefresh(t *testing.T) {
+	server, endpoint := newTokenEndpoint(t, 3600)
+	token := newOAuth2Token(server.URL, "", "", "rt-0")
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 20)
+	for i := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], _ = token.get(context.Background(), server.Client())
+		}()
+	}
+	wg.Wait()
+
+	if endpoint.count() != 1 {
+		t.Errorf("refreshes = %d, want 1 for concurrent callers", endpoint.count())
+	}
+	for i, got := range tokens {
+		if got != "at-1" {
+			t.Errorf("caller %d got %q, want at-1", i, got)
+		}
+	}
+}
+
+func TestOAuth2TokenInvalidatedOn401(t *testing.T) {
+	tokenServer, endpoint := newTokenEndpoint(t, 3600)
+	var mu sync.Mutex
+	var received []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("Authorization"))
+		mu.Unlock()
+		// The first access token is revoked before its expiry
+		if r.Header.Get("Authorization") == "Bearer at-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true}`)
+	}))
+	defer upstream.Close()
+
+	spec := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        authorizationCode:
+          authorizationUrl: https://example.com/authorize
+          tokenUrl: ` + tokenServer.URL + `
+          scopes: {}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      security: [{oauth: []}]
+      responses: {"200": {description: ok}}
+`
+	_, s := convertSpec(t, spec, Options{OAuth2RefreshToken: "rt-0"})
+	for range 3 {
+		callTool(t, s, "listItems", map[string]any{"openapi|server_addr": upstream.URL})
+	}
+
+	if want := []string{"Bearer at-1", "Bearer at-2", "Bearer at-2"}; fmt.Sprint(received) != fmt.Sprint(want) {
+		t.Errorf("Authorization sent = %v, want %v", received, want)
+	}
+	if endpoint.count() != 2 {
+		t.Errorf("refreshes = %d, want 2", endpoint.count())
+	}
+}
@@ -0,0 +1,213 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PaginationConfig describes how list responses link to their next page
+type PaginationConfig struct {
+	// MaxPages bounds the pages fetched per call including the first one, pagination is disabled below 2
+	MaxPages int
+	// CursorPath is the dot path to the next cursor or next page URL in JSON responses,
+	// the next relation of the Link header is followed when empty
+	CursorPath string
+	// CursorParam is the query parameter receiving the cursor when it is not a URL
+	CursorParam string
+	// ItemsPath is the dot path of the list concatenated across pages of object responses
+	ItemsPath string
+}
+
+// followPages fetches the pages following the first response of a GET request
+// and aggregates their bodies, stopping at the first failed page
+func (c *Converter) followPages(ctx context.Context, client *http.Client, req *http.Request, header http.Header, body []byte) ([]byte, error) {
+	config := c.options.Pagination
+	pages := [][]byte{body}
+	current := req.URL
+	for len(pages) < config.MaxPages {
+		next := config.nextPageURL(current, header, body)
+		if next == nil || next.String() == current.String() {
+			break
+		}
+
+		pageReq := req.Clone(ctx)
+		pageReq.URL = next
+		stripCredentials(pageReq, req.URL)
+		resp, err := client.Do(pageReq)
+		if err != nil {
+			return nil, fmt.Errorf("request of page %d failed: %w", len(pages)+1, err)
+		}
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read response of page %d error: %w", len(pages)+1, err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			break
+		}
+
+		pages = append(pages, body)
+		current, header = next, resp.Header
+	}
+	return aggregatePages(pages, config.ItemsPath), nil
+}
+
+// nextPageURL returns the URL of the page following the current one, or nil on the last page
+func (p *PaginationConfig) nextPageURL(current *url.URL, header http.Header, body []byte) *url.URL {
+	if p.CursorPath == "" {
+		next := nextLink(header)
+		if next == "" {
+			return nil
+		}
+		ref, err := url.Parse(next)
+		if err != nil {
+			return nil
+		}
+		return current.ResolveReference(ref)
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil
+	}
+	cursor, ok := lookupPath(value, strings.Split(p.CursorPath, "."))
+	if !ok || cursor == nil {
+		return nil
+	}
+	next := fmt.Sprintf("%v", cursor)
+	if next == "" {
+		return nil
+	}
+
+	// Cursors holding a URL are followed as is
+	if strings.HasPrefix(next, "/") || strings.Contains(next, "://") {
+		ref, err := url.Parse(next)
+		if err != nil {
+			return nil
+		}
+		return current.ResolveReference(ref)
+	}
+	if p.CursorParam == "" {
+		return nil
+	}
+	nextURL := *current
+	query := nextURL.Query()
+	query.Set(p.CursorParam, next)
+	nextURL.RawQuery = query.Encode()
+	return &nextURL
+}
+
+// nextLink returns the target of the next relation of the Link header
+func nextLink(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(link, ";")
+			if !ok {
+				continue
+			}
+			target = strings.TrimSpace(target)
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, rel, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(name, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(rel, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// lookupPath returns the value at a dot path of a decoded JSON object
+func lookupPath(value any, path []string) (any, bool) {
+	for _, key := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// aggregatePages merges the pages of a list: array pages are concatenated, the lists at
+// itemsPath are concatenated into the first page, other JSON pages are returned as an array
+// of pages and pages that are not JSON are separated by newlines
+func aggregatePages(pages [][]byte, itemsPath string) []byte {
+	if len(pages) == 1 {
+		return pages[0]
+	}
+
+	values := make([]any, len(pages))
+	for i, page := range pages {
+		if err := json.Unmarshal(page, &values[i]); err != nil {
+			return bytes.Join(pages, []byte("\n"))
+		}
+	}
+
+	var merged any = values
+	if items, ok := concatArrays(values); ok {
+		merged = items
+	} else if itemsPath != "" {
+		if first, ok := mergeItems(values, strings.Split(itemsPath, ".")); ok {
+			merged = first
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return bytes.Join(pages, []byte("\n"))
+	}
+	return data
+}
+
+// concatArrays concatenates the values when all of them are arrays
+func concatArrays(values []any) ([]any, bool) {
+	var items []any
+	for _, value := range values {
+		array, ok := value.([]any)
+		if !ok {
+			return nil, false
+		}
+		items = append(items, array...)
+	}
+	return items, true
+}
+
+// mergeItems concatenates the lists at path of every page into the first page
+func mergeItems(values []any, path []string) (any, bool) {
+	lists := make([]any, len(values))
+	for i, value := range values {
+		list, ok := lookupPath(value, path)
+		if !ok {
+			return nil, false
+		}
+		lists[i] = list
+	}
+	items, ok := concatArrays(lists)
+	if !ok {
+		return nil, false
+	}
+
+	parent, ok := lookupPath(values[0], path[:len(path)-1])
+	if !ok {
+		return nil, false
+	}
+	parent.(map[string]any)[path[len(path)-1]] = items
+	return values[0], true
+}
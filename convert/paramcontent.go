@@ -0,0 +1,65 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// parameterSchema returns the schema of a parameter, read from its single media type
+// when the parameter is described with content instead of schema
+func parameterSchema(param *openapi3.Parameter) (*openapi3.Schema, string) {
+	if param.Schema != nil {
+		return param.Schema.Value, ""
+	}
+	for contentType, mediaType := range param.Content {
+		if mediaType != nil && mediaType.Schema != nil {
+			return mediaType.Schema.Value, contentType
+		}
+		return nil, contentType
+	}
+	return nil, ""
+}
+
+// getContentParameters returns the media type of each parameter described with content, keyed by in|name
+func getContentParameters(parameters openapi3.Parameters) map[string]string {
+	params := make(map[string]string)
+	for _, paramRef := range parameters {
+		if paramRef.Value == nil {
+			continue
+		}
+		if _, contentType := parameterSchema(paramRef.Value); contentType != "" {
+			params[paramRef.Value.In+"|"+paramRef.Value.Name] = contentType
+		}
+	}
+	return params
+}
+
+// encodeContentParameters replaces the values of parameters described with content by their
+// serialization in the media type, so they are sent as a single string
+func encodeContentParameters(arg *Args, params map[string]string) error {
+	locations := map[string]map[string]any{
+		openapi3.ParameterInQuery:  arg.Query,
+		openapi3.ParameterInPath:   arg.Path,
+		openapi3.ParameterInHeader: arg.Headers,
+	}
+	for in, values := range locations {
+		for name, value := range values {
+			contentType, ok := params[in+"|"+name]
+			if !ok {
+				continue
+			}
+			if !isJSONMediaType(contentType) {
+				values[name] = formatValue(value)
+				continue
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s parameter %s: %w", in, name, err)
+			}
+			values[name] = string(data)
+		}
+	}
+	return nil
+}
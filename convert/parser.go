@@ -1,18 +1,28 @@
 package convert
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi2"
 	"github.com/getkin/kin-openapi/openapi2conv"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
 )
 
+// fetchTimeout bounds fetching an OpenAPI document from a URL
+const fetchTimeout = 30 * time.Second
+
 // Parser represents an OpenAPI parser
 type Parser struct {
 	doc *openapi3.T
+	// sourceURL is the URL the document was loaded from, if any
+	sourceURL string
 }
 
 // NewParser creates a new OpenAPI parser
@@ -30,6 +40,41 @@ func (p *Parser) ParseFile(filePath string) error {
 	return p.Parse(data)
 }
 
+// ParseURL parses an OpenAPI document fetched from a URL within fetchTimeout,
+// relative server URLs of the document are resolved against it
+func (p *Parser) ParseURL(rawURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	return p.ParseURLContext(ctx, rawURL)
+}
+
+// ParseURLContext is ParseURL with the fetch bound by the context instead of fetchTimeout
+func (p *Parser) ParseURLContext(ctx context.Context, rawURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch OpenAPI document: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch OpenAPI document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch OpenAPI document: status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read OpenAPI document: %w", err)
+	}
+
+	if err := p.Parse(data); err != nil {
+		return err
+	}
+	p.sourceURL = rawURL
+	return nil
+}
+
 func (p *Parser) ParseFileV2(filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -54,8 +99,14 @@ func (p *Parser) Parse(data []byte) error {
 }
 
 func (p *Parser) ParseV2(data []byte) error {
+	// Swagger 2.0 documents are often YAML, convert them to JSON first (JSON is valid YAML)
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+
 	var doc2 openapi2.T
-	err := doc2.UnmarshalJSON(data)
+	err = doc2.UnmarshalJSON(data)
 	if err != nil {
 		return fmt.Errorf("failed to parse OpenAPI document: %w", err)
 	}
@@ -64,16 +115,81 @@ func (p *Parser) ParseV2(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to convert OpenAPI document: %w", err)
 	}
+	applyCollectionFormats(&doc2, doc3)
 
 	p.doc = doc3
 	return nil
 }
 
+// applyCollectionFormats maps the collectionFormat of Swagger 2.0 query parameters to
+// the style and explode of the converted parameters, which openapi2conv drops
+func applyCollectionFormats(doc2 *openapi2.T, doc3 *openapi3.T) {
+	apply := func(param2 *openapi2.Parameter, param3 *openapi3.Parameter) {
+		if param2 == nil || param3 == nil || param2.In != openapi3.ParameterInQuery || param2.Type == nil || !param2.Type.Is("array") {
+			return
+		}
+		explode := false
+		switch param2.CollectionFormat {
+		case "", "csv":
+			param3.Style = openapi3.SerializationForm
+		case "ssv":
+			param3.Style = openapi3.SerializationSpaceDelimited
+		case "pipes":
+			param3.Style = openapi3.SerializationPipeDelimited
+		case "tsv":
+			param3.Style = serializationTabDelimited
+		case "multi":
+			param3.Style = openapi3.SerializationForm
+			explode = true
+		default:
+			return
+		}
+		param3.Explode = &explode
+	}
+
+	if doc3.Components != nil {
+		for name, param2 := range doc2.Parameters {
+			if paramRef := doc3.Components.Parameters[name]; paramRef != nil {
+				apply(param2, paramRef.Value)
+			}
+		}
+	}
+
+	for path, pathItem2 := range doc2.Paths {
+		pathItem3 := doc3.Paths.Value(path)
+		if pathItem3 == nil {
+			continue
+		}
+		applyAll := func(params2 openapi2.Parameters, params3 openapi3.Parameters) {
+			for _, param2 := range params2 {
+				// Referenced parameters were handled with the components
+				if param2.Ref != "" {
+					continue
+				}
+				if paramRef := params3.GetByInAndName(param2.In, param2.Name); paramRef != nil {
+					apply(param2, paramRef)
+				}
+			}
+		}
+		applyAll(pathItem2.Parameters, pathItem3.Parameters)
+		for method, operation2 := range pathItem2.Operations() {
+			if operation3 := pathItem3.GetOperation(method); operation3 != nil {
+				applyAll(operation2.Parameters, operation3.Parameters)
+			}
+		}
+	}
+}
+
 // GetDocument returns the parsed OpenAPI document
 func (p *Parser) GetDocument() *openapi3.T {
 	return p.doc
 }
 
+// GetSourceURL returns the URL the document was loaded from, empty when not loaded from a URL
+func (p *Parser) GetSourceURL() string {
+	return p.sourceURL
+}
+
 // GetPaths returns all paths in the OpenAPI document
 func (p *Parser) GetPaths() *openapi3.Paths {
 	if p.doc == nil {
@@ -0,0 +1,102 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestParseV2CollectionFormats(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseFileV2("testdata/collection-formats.v2.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	pathItem := parser.GetDocument().Paths.Value("/items")
+	parameters := slices.Concat(pathItem.Parameters, pathItem.GetOperation(http.MethodGet).Parameters)
+	params := getQueryParams(parameters, "")
+
+	tests := []struct {
+		name    string
+		style   string
+		explode bool
+	}{
+		{name: "default", style: openapi3.SerializationForm},
+		{name: "csv", style: openapi3.SerializationForm},
+		{name: "ssv", style: openapi3.SerializationSpaceDelimited},
+		{name: "tsv", style: serializationTabDelimited},
+		{name: "pipes", style: openapi3.SerializationPipeDelimited},
+		{name: "multi", style: openapi3.SerializationForm, explode: true},
+		{name: "shared", style: openapi3.SerializationPipeDelimited},
+		{name: "pathLevel", style: openapi3.SerializationSpaceDelimited},
+		{name: "scalar", style: openapi3.SerializationForm, explode: true},
+	}
+	for _, tt := range tests {
+		param, ok := params[tt.name]
+		if !ok {
+			t.Errorf("%s: query parameter missing", tt.name)
+			continue
+		}
+		if param.style != tt.style || param.explode != tt.explode {
+			t.Errorf("%s: style %s explode %v, want %s explode %v", tt.name, param.style, param.explode, tt.style, tt.explode)
+		}
+	}
+	if header := parameters.GetByInAndName(openapi3.ParameterInHeader, "X-Tags"); header == nil || header.Style != "" {
+		t.Errorf("header parameter = %+v, want its style left unset", header)
+	}
+
+	upstream, recorded := newUpstream(t)
+	converter := NewConverter(parser, Options{})
+	s, err := converter.Convert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := map[string]any{"openapi|server_addr": upstream.URL, "query|scalar": "x"}
+	for _, tt := range tests[:len(tests)-1] {
+		args["query|"+tt.name] = []any{"a", "b"}
+	}
+	if text, ok := callTool(t, s, "listItems", args); !ok {
+		t.Fatal(text)
+	}
+	want := "csv=a,b&default=a,b&multi=a&multi=b&pathLevel=a%20b&pipes=a|b&scalar=x&shared=a|b&ssv=a%20b&tsv=a%09b"
+	if recorded.Query != want {
+		t.Errorf("query = %s, want %s", recorded.Query, want)
+	}
+}
+
+func TestParseURLContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.yaml" {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		io.WriteString(w, `{"openapi": "3.0.0", "info": {"title": "test", "version": "1"}, "paths": {}}`)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	if err := NewParser().ParseURLContext(context.Background(), server.URL+"/openapi.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := NewParser().ParseURLContext(ctx, server.URL+"/slow.yaml")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fetch returned after %v, want it stopped at the deadline", elapsed)
+	}
+}
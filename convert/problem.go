@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+const contentTypeProblemJSON = "application/problem+json"
+
+// problemDetails holds the standard members of an RFC 7807 problem details object
+type problemDetails struct {
+	Type     string   `json:"type"`
+	Title    string   `json:"title"`
+	Status   *float64 `json:"status"`
+	Detail   string   `json:"detail"`
+	Instance string   `json:"instance"`
+}
+
+// problemSummary returns the lines explaining an application/problem+json response,
+// or an empty string when the response is not a valid problem details object
+func problemSummary(contentType string, body []byte) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.EqualFold(mediaType, contentTypeProblemJSON) {
+		return ""
+	}
+	var problem problemDetails
+	if err := json.Unmarshal(body, &problem); err != nil {
+		return ""
+	}
+
+	var lines []string
+	if problem.Title != "" {
+		lines = append(lines, "problem: "+problem.Title)
+	}
+	if problem.Detail != "" {
+		lines = append(lines, "detail: "+problem.Detail)
+	}
+	if problem.Status != nil {
+		lines = append(lines, fmt.Sprintf("problem status: %v", *problem.Status))
+	}
+	// about:blank is the default type and carries no information
+	if problem.Type != "" && problem.Type != "about:blank" {
+		lines = append(lines, "problem type: "+problem.Type)
+	}
+	if problem.Instance != "" {
+		lines = append(lines, "instance: "+problem.Instance)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
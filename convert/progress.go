@@ -0,0 +1,52 @@
+package convert
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// progressInterval is how often progress is reported while an upstream request is in flight
+const progressInterval = 5 * time.Second
+
+// reportProgress periodically sends progress notifications to the client when the call
+// carries a progress token, until the returned stop function is called
+func reportProgress(ctx context.Context, request mcp.CallToolRequest) (stop func()) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return func() {}
+	}
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return func() {}
+	}
+
+	token := request.Params.Meta.ProgressToken
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		var progress float64
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				progress++
+				_ = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+					"progressToken": token,
+					"progress":      progress,
+					"message":       "still waiting on upstream",
+				})
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,111 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// projectResponse keeps only the selected dot-path fields of a JSON response body,
+// bodies that are not JSON are returned unchanged
+func projectResponse(body []byte, fields []string) []byte {
+	if len(fields) == 0 {
+		return body
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return body
+	}
+
+	paths := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			paths = append(paths, strings.Split(field, "."))
+		}
+	}
+
+	projected, err := json.Marshal(projectFields(value, paths))
+	if err != nil {
+		return body
+	}
+	return projected
+}
+
+// projectFields selects the paths from a decoded JSON value,
+// paths apply to each element of arrays so "items.id" selects the id of every item
+func projectFields(value any, paths [][]string) any {
+	switch value := value.(type) {
+	case []any:
+		projected := make([]any, len(value))
+		for i, item := range value {
+			projected[i] = projectFields(item, paths)
+		}
+		return projected
+	case map[string]any:
+		whole := make(map[string]bool)
+		nested := make(map[string][][]string)
+		for _, path := range paths {
+			if len(path) == 1 {
+				whole[path[0]] = true
+			} else {
+				nested[path[0]] = append(nested[path[0]], path[1:])
+			}
+		}
+
+		projected := make(map[string]any)
+		for key := range whole {
+			if v, ok := value[key]; ok {
+				projected[key] = v
+			}
+		}
+		for key, rest := range nested {
+			if v, ok := value[key]; ok && !whole[key] {
+				projected[key] = projectFields(v, rest)
+			}
+		}
+		return projected
+	default:
+		return value
+	}
+}
+
+// extractPointer returns the subtree of a JSON response body addressed by an RFC 6901 JSON Pointer
+func extractPointer(body []byte, pointer string) ([]byte, error) {
+	if pointer == "" {
+		return body, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with /", pointer)
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, fmt.Errorf("can't apply JSON pointer %q to a response that is not JSON: %w", pointer, err)
+	}
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := value.(type) {
+		case map[string]any:
+			member, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("JSON pointer %q: member %q not found", pointer, token)
+			}
+			value = member
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+				return nil, fmt.Errorf("JSON pointer %q: invalid array index %q", pointer, token)
+			}
+			if index >= len(v) {
+				return nil, fmt.Errorf("JSON pointer %q: array index %d out of range, the array has %d items", pointer, index, len(v))
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("JSON pointer %q: can't look up %q in a scalar value", pointer, token)
+		}
+	}
+	return json.Marshal(value)
+}
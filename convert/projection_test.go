@@ -0,0 +1,49 @@
+package convert
+
+import "testing"
+
+func TestExtractPointer(t *testing.T) {
+	body := []byte(`{"data":{"items":[{"id":1},{"id":2}]},"a/b":"slash","m~n":"tilde","~1":"literal","":"empty"}`)
+	tests := []struct {
+		pointer string
+		want    string
+		wantErr bool
+	}{
+		{pointer: "", want: string(body)},
+		{pointer: "/data/items", want: `[{"id":1},{"id":2}]`},
+		{pointer: "/data/items/1/id", want: `2`},
+		{pointer: "/a~1b", want: `"slash"`},
+		{pointer: "/m~0n", want: `"tilde"`},
+		// ~01 decodes to ~1, not to /
+		{pointer: "/~01", want: `"literal"`},
+		{pointer: "/", want: `"empty"`},
+		{pointer: "/missing", wantErr: true},
+		{pointer: "/data/missing/id", wantErr: true},
+		{pointer: "/data/items/2", wantErr: true},
+		{pointer: "/data/items/01", wantErr: true},
+		{pointer: "/data/items/-1", wantErr: true},
+		{pointer: "/data/items/0/id/x", wantErr: true},
+		{pointer: "data", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := extractPointer(body, tt.pointer)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := extractPointer([]byte("not json"), "/a"); err == nil {
+		t.Error("expected an error for a body that is not JSON")
+	}
+}
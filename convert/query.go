@@ -0,0 +1,212 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// serializationTabDelimited is the non-standard style of Swagger 2.0 tsv collections
+const serializationTabDelimited = "tabDelimited"
+
+// Encodings of boolean query parameters, selected globally or per parameter with x-mcp-bool-format
+const (
+	// BoolFormatLiteral sends true or false
+	BoolFormatLiteral = "literal"
+	// BoolFormatNumeric sends 1 or 0
+	BoolFormatNumeric = "numeric"
+	// BoolFormatPresence sends the bare parameter name for true and omits the parameter for false
+	BoolFormatPresence = "presence"
+)
+
+// queryParam describes how a query parameter is serialized
+type queryParam struct {
+	style         string
+	explode       bool
+	allowReserved bool
+	boolFormat    string
+}
+
+// getQueryParams returns the serialization of each query parameter by name,
+// booleans use boolFormat unless the parameter sets x-mcp-bool-format
+func getQueryParams(parameters openapi3.Parameters, boolFormat string) map[string]queryParam {
+	params := make(map[string]queryParam)
+	for _, paramRef := range parameters {
+		param := paramRef.Value
+		if param == nil || param.In != openapi3.ParameterInQuery {
+			continue
+		}
+		sm, err := param.SerializationMethod()
+		if err != nil {
+			continue
+		}
+		format := boolFormat
+		if value, ok := param.Extensions["x-mcp-bool-format"].(string); ok {
+			format = value
+		}
+		params[param.Name] = queryParam{
+			style:         sm.Style,
+			explode:       sm.Explode,
+			allowReserved: param.AllowReserved,
+			boolFormat:    format,
+		}
+	}
+	return params
+}
+
+// encodeQuery appends the query arguments to a raw query string.
+//
+// Arrays and objects follow the parameter style and explode settings. Objects nested
+// inside arrays or objects can't be expressed by these styles, so they are sent
+// JSON-encoded, e.g. an exploded array of objects repeats the key once per JSON object.
+func encodeQuery(rawQuery string, values map[string]any, params map[string]queryParam) string {
+	var query []string
+	if rawQuery != "" {
+		query = append(query, rawQuery)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		param, ok := params[key]
+		if !ok {
+			param = queryParam{style: openapi3.SerializationForm, explode: true}
+		}
+		query = append(query, encodeQueryParam(key, values[key], param)...)
+	}
+
+	return strings.Join(query, "&")
+}
+
+// encodeQueryParam serializes a single query parameter into encoded key=value pairs
+func encodeQueryParam(name string, value any, param queryParam) []string {
+	escape := url.QueryEscape
+	if param.allowReserved {
+		escape = escapeReserved
+	}
+	pair := func(key, value string) string {
+		return url.QueryEscape(key) + "=" + value
+	}
+
+	switch value := value.(type) {
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, escape(formatValue(item)))
+		}
+		switch {
+		case param.style == openapi3.SerializationSpaceDelimited:
+			return []string{pair(name, strings.Join(items, "%20"))}
+		case param.style == openapi3.SerializationPipeDelimited:
+			return []string{pair(name, strings.Join(items, "|"))}
+		case param.style == serializationTabDelimited:
+			return []string{pair(name, strings.Join(items, "%09"))}
+		case param.explode:
+			pairs := make([]string, 0, len(items))
+			for _, item := range items {
+				pairs = append(pairs, pair(name, item))
+			}
+			return pairs
+		default:
+			return []string{pair(name, strings.Join(items, ","))}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		switch {
+		case param.style == openapi3.SerializationDeepObject:
+			pairs := make([]string, 0, len(keys))
+			for _, key := range keys {
+				pairs = append(pairs, pair(name+"["+key+"]", escape(formatValue(value[key]))))
+			}
+			return pairs
+		case param.explode:
+			pairs := make([]string, 0, len(keys))
+			for _, key := range keys {
+				pairs = append(pairs, pair(key, escape(formatValue(value[key]))))
+			}
+			return pairs
+		default:
+			items := make([]string, 0, len(keys)*2)
+			for _, key := range keys {
+				items = append(items, escape(key), escape(formatValue(value[key])))
+			}
+			return []string{pair(name, strings.Join(items, ","))}
+		}
+	case bool:
+		switch param.boolFormat {
+		case BoolFormatNumeric:
+			if value {
+				return []string{pair(name, "1")}
+			}
+			return []string{pair(name, "0")}
+		case BoolFormatPresence:
+			if value {
+				return []string{url.QueryEscape(name)}
+			}
+			return nil
+		default:
+			return []string{pair(name, strconv.FormatBool(value))}
+		}
+	default:
+		return []string{pair(name, escape(formatValue(value)))}
+	}
+}
+
+// formatValue formats a scalar argument as a string, arrays and objects are JSON-encoded
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []any, map[string]any:
+		str, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%v", value)
+		}
+		return string(str)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// escapeReserved percent-encodes a query value while keeping RFC 3986 reserved
+// characters and existing percent-encoded triplets literal
+func escapeReserved(s string) string {
+	const reserved = ":/?[]@!$&'()*+,;="
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '.', ch == '_', ch == '~':
+			b.WriteByte(ch)
+		case strings.IndexByte(reserved, ch) >= 0:
+			b.WriteByte(ch)
+		case ch == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func isHex(ch byte) bool {
+	return '0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
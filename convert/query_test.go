@@ -0,0 +1,156 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestEncodeQueryParam(t *testing.T) {
+	array := []any{"a b", "c", float64(1)}
+	object := map[string]any{"role": "admin", "first name": "Alex"}
+	tests := []struct {
+		name  string
+		value any
+		param queryParam
+		want  string
+	}{
+		{name: "form exploded array", value: array, param: queryParam{style: openapi3.SerializationForm, explode: true}, want: "ids=a+b&ids=c&ids=1"},
+		{name: "form array", value: array, param: queryParam{style: openapi3.SerializationForm}, want: "ids=a+b,c,1"},
+		{name: "spaceDelimited array", value: array, param: queryParam{style: openapi3.SerializationSpaceDelimited}, want: "ids=a+b%20c%201"},
+		{name: "pipeDelimited array", value: array, param: queryParam{style: openapi3.SerializationPipeDelimited}, want: "ids=a+b|c|1"},
+		{name: "tabDelimited array", value: array, param: queryParam{style: serializationTabDelimited}, want: "ids=a+b%09c%091"},
+		{name: "form exploded object", value: object, param: queryParam{style: openapi3.SerializationForm, explode: true}, want: "first+name=Alex&role=admin"},
+		{name: "form object", value: object, param: queryParam{style: openapi3.SerializationForm}, want: "ids=first+name,Alex,role,admin"},
+		{name: "deepObject", value: object, param: queryParam{style: openapi3.SerializationDeepObject, explode: true}, want: "ids%5Bfirst+name%5D=Alex&ids%5Brole%5D=admin"},
+		{name: "nested object", value: []any{map[string]any{"a": float64(1)}}, param: queryParam{style: openapi3.SerializationForm, explode: true}, want: "ids=%7B%22a%22%3A1%7D"},
+		{name: "scalar", value: "x&y=z", param: queryParam{style: openapi3.SerializationForm, explode: true}, want: "ids=x%26y%3Dz"},
+		{name: "number", value: float64(1.5), param: queryParam{style: openapi3.SerializationForm, explode: true}, want: "ids=1.5"},
+		{name: "empty array", value: []any{}, param: queryParam{style: openapi3.SerializationForm, explode: true}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(encodeQueryParam("ids", tt.value, tt.param), "&"); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryStylesFromSpec(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	spec := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      parameters:
+        - {name: form, in: query, explode: false, schema: {type: array, items: {type: string}}}
+        - {name: space, in: query, style: spaceDelimited, explode: false, schema: {type: array, items: {type: string}}}
+        - {name: pipe, in: query, style: pipeDelimited, explode: false, schema: {type: array, items: {type: string}}}
+        - {name: filter, in: query, style: deepObject, explode: true, schema: {type: object}}
+      responses: {"200": {description: ok}}
+`
+	_, s := convertSpec(t, spec, Options{})
+	text, ok := callTool(t, s, "listItems", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"query|form":          []any{"a", "b"},
+		"query|space":         []any{"a", "b"},
+		"query|pipe":          []any{"a", "b"},
+		"query|filter":        map[string]any{"x": "1", "y": "2"},
+	})
+	if !ok {
+		t.Fatal(text)
+	}
+	if want := "filter%5Bx%5D=1&filter%5By%5D=2&form=a,b&pipe=a|b&space=a%20b"; recorded.Query != want {
+		t.Errorf("query = %s, want %s", recorded.Query, want)
+	}
+}
+
+func TestEscapeReserved(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "plain-._~", want: "plain-._~"},
+		{value: "a/b?c=d&e", want: "a/b?c=d&e"},
+		{value: ":[]@!$'()*+,;", want: ":[]@!$'()*+,;"},
+		{value: "a b", want: "a%20b"},
+		{value: "#frag", want: "%23frag"},
+		{value: "é", want: "%C3%A9"},
+		{value: "%41%2f", want: "%41%2f"},
+		{value: "%", want: "%25"},
+		{value: "100%", want: "100%25"},
+		{value: "%4", want: "%254"},
+		{value: "%zz", want: "%25zz"},
+		{value: "a%2Fb%", want: "a%2Fb%25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := escapeReserved(tt.value); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	param := queryParam{style: openapi3.SerializationForm, allowReserved: true}
+	if got := strings.Join(encodeQueryParam("path", []any{"a/b", "c%2Cd"}, param), "&"); got != "path=a/b,c%2Cd" {
+		t.Errorf("allowReserved array = %s, want path=a/b,c%%2Cd", got)
+	}
+}
+
+func TestEncodeQueryParamBoolFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		value  bool
+		want   string
+	}{
+		{format: "", value: true, want: "flag=true"},
+		{format: BoolFormatLiteral, value: false, want: "flag=false"},
+		{format: BoolFormatNumeric, value: true, want: "flag=1"},
+		{format: BoolFormatNumeric, value: false, want: "flag=0"},
+		{format: BoolFormatPresence, value: true, want: "flag"},
+		{format: BoolFormatPresence, value: false, want: ""},
+	}
+	for _, tt := range tests {
+		param := queryParam{style: openapi3.SerializationForm, explode: true, boolFormat: tt.format}
+		if got := strings.Join(encodeQueryParam("flag", tt.value, param), "&"); got != tt.want {
+			t.Errorf("%q %v: got %q, want %q", tt.format, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBoolFormatFromSpec(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	spec := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      parameters:
+        - {name: a, in: query, schema: {type: boolean}}
+        - {name: b, in: query, schema: {type: boolean}}
+        - {name: c, in: query, x-mcp-bool-format: presence, schema: {type: boolean}}
+        - {name: d, in: query, x-mcp-bool-format: presence, schema: {type: boolean}}
+      responses: {"200": {description: ok}}
+`
+	_, s := convertSpec(t, spec, Options{QueryBoolFormat: BoolFormatNumeric})
+	text, ok := callTool(t, s, "listItems", map[string]any{
+		"openapi|server_addr": upstream.URL,
+		"query|a":             true,
+		"query|b":             false,
+		"query|c":             true,
+		"query|d":             false,
+	})
+	if !ok {
+		t.Fatal(text)
+	}
+	if want := "a=1&b=0&c"; recorded.Query != want {
+		t.Errorf("query = %q, want %q", recorded.Query, want)
+	}
+}
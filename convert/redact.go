@@ -0,0 +1,221 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// redacted replaces sensitive values in request logs
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are always redacted from request logs and audit records
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveFields are the fields of an operation declared with format password
+type sensitiveFields struct {
+	// params holds parameter names keyed like tool arguments, e.g. query|password
+	params map[string]bool
+	// body holds property names of the request body at any depth
+	body map[string]bool
+}
+
+// getSensitiveFields collects the parameters and request body properties declared with format password
+func getSensitiveFields(parameters openapi3.Parameters, requestBody *openapi3.RequestBodyRef) sensitiveFields {
+	fields := sensitiveFields{
+		params: make(map[string]bool),
+		body:   make(map[string]bool),
+	}
+	for _, paramRef := range parameters {
+		param := paramRef.Value
+		if param == nil || param.Schema == nil || param.Schema.Value == nil || param.Schema.Value.Format != "password" {
+			continue
+		}
+		fields.params[sensitiveParamKey(param.In, param.Name)] = true
+	}
+	if requestBody != nil && requestBody.Value != nil {
+		for _, mediaType := range requestBody.Value.Content {
+			if mediaType.Schema != nil {
+				collectPasswordProperties(mediaType.Schema.Value, fields, make(map[*openapi3.Schema]bool))
+			}
+		}
+	}
+	return fields
+}
+
+// sensitiveParamKey keys a parameter by location and name, header names being case-insensitive
+func sensitiveParamKey(in, name string) string {
+	if in == openapi3.ParameterInHeader {
+		name = http.CanonicalHeaderKey(name)
+	}
+	return in + "|" + name
+}
+
+// collectPasswordProperties records the names of password properties of a body schema,
+// form fields being recorded as formData parameters too
+func collectPasswordProperties(schema *openapi3.Schema, fields sensitiveFields, visited map[*openapi3.Schema]bool) {
+	if schema == nil || visited[schema] {
+		return
+	}
+	visited[schema] = true
+
+	for name, property := range schema.Properties {
+		if property.Value == nil {
+			continue
+		}
+		if property.Value.Format == "password" {
+			fields.body[name] = true
+			fields.params[sensitiveParamKey("formData", name)] = true
+		}
+		collectPasswordProperties(property.Value, fields, visited)
+	}
+	if schema.Items != nil {
+		collectPasswordProperties(schema.Items.Value, fields, visited)
+	}
+	for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for _, ref := range refs {
+			collectPasswordProperties(ref.Value, fields, visited)
+		}
+	}
+}
+
+// redactURL returns the URL of a request with password query parameters redacted
+func (s sensitiveFields) redactURL(u *url.URL) string {
+	redactedURL := *u
+	query := redactedURL.Query()
+	for name := range query {
+		if s.params[sensitiveParamKey(openapi3.ParameterInQuery, name)] {
+			query[name] = []string{redacted}
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
+
+// redactError returns the error of a failed request with password query parameters of its URL redacted
+func (s sensitiveFields) redactError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: s.redactURL(u), Err: urlErr.Err}
+}
+
+// redactHeader returns a copy of the header with credentials and password parameters redacted
+func (s sensitiveFields) redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range sensitiveHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, redacted)
+		}
+	}
+	for name := range header {
+		if s.params[sensitiveParamKey(openapi3.ParameterInHeader, name)] {
+			header.Set(name, redacted)
+		}
+	}
+	return header
+}
+
+// logRequest logs an upstream request with credentials and password fields redacted
+func (s sensitiveFields) logRequest(req *http.Request, arg Args) {
+	header := s.redactHeader(req.Header)
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name+": "+strings.Join(header[name], ", "))
+	}
+	sort.Strings(names)
+
+	line := fmt.Sprintf("request: %s %s headers=[%s]", req.Method, s.redactURL(req.URL), strings.Join(names, "; "))
+	if arg.Body != nil {
+		body, err := json.Marshal(s.redactValue(arg.Body))
+		if err == nil {
+			line += " body=" + string(body)
+		}
+	}
+	if len(arg.Forms) > 0 {
+		forms := url.Values{}
+		for name, value := range arg.Forms {
+			if s.params[sensitiveParamKey("formData", name)] {
+				forms.Set(name, redacted)
+			} else {
+				forms.Set(name, formatValue(value))
+			}
+		}
+		line += " form=" + forms.Encode()
+	}
+	log.Print(line)
+}
+
+// redactValue returns a copy of a decoded JSON value with password properties redacted
+func (s sensitiveFields) redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		redactedValue := make(map[string]any, len(value))
+		for k, v := range value {
+			if s.body[k] {
+				redactedValue[k] = redacted
+			} else {
+				redactedValue[k] = s.redactValue(v)
+			}
+		}
+		return redactedValue
+	case []any:
+		redactedValue := make([]any, len(value))
+		for i, v := range value {
+			redactedValue[i] = s.redactValue(v)
+		}
+		return redactedValue
+	default:
+		return value
+	}
+}
+
+// auditRecord describes an upstream exchange for audit, without secrets
+type auditRecord struct {
+	Request  auditRequest  `json:"request"`
+	Response auditResponse `json:"response"`
+}
+
+type auditRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+}
+
+type auditResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers"`
+}
+
+// auditContent returns the audit record of an upstream exchange as a text content block
+func (s sensitiveFields) auditContent(req *http.Request, resp *http.Response) (mcp.Content, error) {
+	record := auditRecord{
+		Request: auditRequest{
+			Method:  req.Method,
+			URL:     s.redactURL(req.URL),
+			Headers: s.redactHeader(req.Header),
+		},
+		Response: auditResponse{
+			Status:  resp.StatusCode,
+			Headers: s.redactHeader(resp.Header),
+		},
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewTextContent("audit record: " + string(data)), nil
+}
@@ -0,0 +1,171 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const redactSpec = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    key: {type: apiKey, in: header, name: X-Api-Key}
+    query: {type: apiKey, in: query, name: api_key}
+  schemas:
+    Credentials:
+      type: object
+      properties:
+        username: {type: string}
+        password: {type: string, format: password}
+paths:
+  /login:
+    post:
+      operationId: login
+      security: [{bearer: [], key: [], query: []}]
+      parameters:
+        - {name: pin, in: query, schema: {type: string, format: password}}
+        - {name: X-Otp, in: header, schema: {type: string, format: password}}
+        - {name: page, in: query, schema: {type: string}}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                account: {$ref: "#/components/schemas/Credentials"}
+                backups:
+                  type: array
+                  items: {$ref: "#/components/schemas/Credentials"}
+      responses: {"200": {description: ok}}
+`
+
+// redactArgs are arguments of redactSpec whose secret values all start with "secret-"
+func redactArgs(serverURL string) map[string]any {
+	return map[string]any{
+		"openapi|server_addr": serverURL,
+		"openapi|auth_token":  "secret-token",
+		"openapi|auth_key":    "secret-header-key",
+		"openapi|auth_query":  "secret-query-key",
+		"query|pin":           "secret-pin",
+		"query|page":          "visible-page",
+		"header|X-Otp":        "secret-otp",
+		"body": map[string]any{
+			"account": map[string]any{"username": "visible-user", "password": "secret-password"},
+			"backups": []any{map[string]any{"username": "visible-backup", "password": "secret-backup"}},
+		},
+	}
+}
+
+// captureLog returns what the standard logger printed while running fn
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(writer)
+	fn()
+	return buf.String()
+}
+
+// checkRedacted fails when text carries a secret of redactArgs or lacks one of the visible values
+func checkRedacted(t *testing.T, text string, visible ...string) {
+	t.Helper()
+	if strings.Contains(text, "secret-") {
+		t.Errorf("secret leaked: %s", text)
+	}
+	for _, value := range visible {
+		if !strings.Contains(text, value) {
+			t.Errorf("%q is missing from %s", value, text)
+		}
+	}
+}
+
+func TestLogRequestRedactsSecrets(t *testing.T) {
+	upstream, recorded := newUpstream(t)
+	_, s := convertSpec(t, redactSpec, Options{LogRequests: true})
+	var text string
+	var ok bool
+	logged := captureLog(t, func() {
+		text, ok = callTool(t, s, "login", redactArgs(upstream.URL))
+	})
+	if !ok {
+		t.Fatal(text)
+	}
+	if !strings.Contains(recorded.Body, "secret-password") || recorded.Header.Get("X-Otp") != "secret-otp" {
+		t.Fatalf("upstream didn't receive the secrets: %s %v", recorded.Body, recorded.Header)
+	}
+	checkRedacted(t, logged, "visible-page", "visible-user", "visible-backup", redacted)
+}
+
+func TestRequestErrorRedactsSecrets(t *testing.T) {
+	upstream, _ := newUpstream(t)
+	serverURL := upstream.URL
+	upstream.Close()
+
+	_, s := convertSpec(t, redactSpec, Options{})
+	text, ok := callTool(t, s, "login", redactArgs(serverURL))
+	if ok {
+		t.Fatalf("expected the request to a closed server to fail, got %s", text)
+	}
+	checkRedacted(t, text, "request failed", "visible-page")
+}
+
+func TestAuditRecordRedactsSecrets(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-cookie"})
+		w.Header().Set("X-Otp", "secret-otp-echo")
+		w.Header().Set("X-Request-Id", "visible-id")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	defer upstream.Close()
+
+	_, s := convertSpec(t, redactSpec, Options{AuditMode: true})
+	text, ok := callTool(t, s, "login", redactArgs(upstream.URL))
+	if !ok {
+		t.Fatal(text)
+	}
+	_, audit, found := strings.Cut(text, "audit record: ")
+	if !found {
+		t.Fatalf("no audit record in %s", text)
+	}
+	var record auditRecord
+	if err := json.Unmarshal([]byte(audit), &record); err != nil {
+		t.Fatal(err)
+	}
+	checkRedacted(t, audit, "visible-page", "visible-id")
+
+	for _, header := range []struct {
+		name   string
+		values []string
+	}{
+		{"request Authorization", record.Request.Headers.Values("Authorization")},
+		{"request X-Api-Key", record.Request.Headers.Values("X-Api-Key")},
+		{"request X-Otp", record.Request.Headers.Values("X-Otp")},
+		{"response Set-Cookie", record.Response.Headers.Values("Set-Cookie")},
+		{"response X-Otp", record.Response.Headers.Values("X-Otp")},
+	} {
+		if len(header.values) != 1 || header.values[0] != redacted {
+			t.Errorf("%s = %v, want %s", header.name, header.values, redacted)
+		}
+	}
+	u, err := url.Parse(record.Request.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query := u.Query(); query.Get("pin") != redacted || query.Get("api_key") != redacted {
+		t.Errorf("audited URL = %s, want pin and api_key redacted", record.Request.URL)
+	}
+	if record.Response.Status != http.StatusOK {
+		t.Errorf("audited status = %d, want 200", record.Response.Status)
+	}
+}
@@ -0,0 +1,68 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	responseResourceScheme = "openapi://responses/"
+	// maxStoredResponses bounds the memory used by stored responses
+	maxStoredResponses = 100
+)
+
+// responseStore keeps upstream responses so clients can read them as resources on demand
+type responseStore struct {
+	mu        sync.RWMutex
+	responses map[string]mcp.TextResourceContents
+	order     []string
+}
+
+func newResponseStore() *responseStore {
+	return &responseStore{
+		responses: make(map[string]mcp.TextResourceContents),
+	}
+}
+
+// resourceTemplate returns the template matching the URIs of stored responses
+func (s *responseStore) resourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(responseResourceScheme+"{id}", "OpenAPI responses",
+		mcp.WithTemplateDescription("Responses of OpenAPI tool calls"))
+}
+
+// add stores a response and returns its resource URI, evicting the oldest response when full
+func (s *responseStore) add(mimeType, text string) string {
+	uri := responseResourceScheme + uuid.NewString()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.order) >= maxStoredResponses {
+		delete(s.responses, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.responses[uri] = mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: mimeType,
+		Text:     text,
+	}
+	s.order = append(s.order, uri)
+
+	return uri
+}
+
+// read is the resource template handler serving stored responses
+func (s *responseStore) read(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	contents, ok := s.responses[request.Params.URI]
+	if !ok {
+		return nil, fmt.Errorf("response %s not found", request.Params.URI)
+	}
+	return []mcp.ResourceContents{contents}, nil
+}
@@ -0,0 +1,32 @@
+package convert
+
+import "fmt"
+
+// ConvertStats summarizes the last conversion
+type ConvertStats struct {
+	// Converted is the number of operations exposed as tools
+	Converted int
+	// Filtered is the number of operations excluded by the options
+	Filtered int
+	// Skipped is the number of operations skipped because they failed to convert
+	Skipped int
+	// Warnings is the number of conversion warnings emitted
+	Warnings int
+}
+
+func (s ConvertStats) String() string {
+	return fmt.Sprintf("%d tools converted, %d operations filtered, %d skipped, %d warnings",
+		s.Converted, s.Filtered, s.Skipped, s.Warnings)
+}
+
+// Stats returns the statistics of the last conversion
+func (c *Converter) Stats() ConvertStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return ConvertStats{
+		Converted: len(c.tools),
+		Filtered:  c.filtered,
+		Skipped:   len(c.skipped),
+		Warnings:  len(c.warnings),
+	}
+}
@@ -0,0 +1,41 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenFileTTL is how long the token read from a token file is reused before reading it again
+const tokenFileTTL = 10 * time.Second
+
+// tokenFile reads a bearer token from a file rotated by another process
+type tokenFile struct {
+	mu     sync.Mutex
+	path   string
+	token  string
+	readAt time.Time
+}
+
+func newTokenFile(path string) *tokenFile {
+	return &tokenFile{path: path}
+}
+
+// get returns the token of the file, reading it again once the cached one is older than tokenFileTTL
+func (f *tokenFile) get() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.readAt.IsZero() && time.Since(f.readAt) < tokenFileTTL {
+		return f.token, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read auth token file: %w", err)
+	}
+	f.token = strings.TrimSpace(string(data))
+	f.readAt = time.Now()
+	return f.token, nil
+}
@@ -0,0 +1,50 @@
+package convert
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pathVersionPattern matches path segments naming an API version, e.g. v1, v2.1 or v1beta2
+var pathVersionPattern = regexp.MustCompile(`(?i)^v\d+(\.\d+)*([a-z]+\d*)?$`)
+
+// apiVersion returns the API version of an operation and whether it was taken from its path,
+// the path prefix and then the version of the document are used when no path segment names one
+func (c *Converter) apiVersion(path string) (string, bool) {
+	if version := pathVersion(path); version != "" {
+		return version, true
+	}
+	if version := pathVersion(c.options.PathPrefix); version != "" {
+		return version, false
+	}
+	if info := c.parser.GetInfo(); info != nil {
+		return info.Version, false
+	}
+	return "", false
+}
+
+// pathVersion returns the first segment of a path naming an API version
+func pathVersion(path string) string {
+	for _, segment := range strings.Split(path, "/") {
+		if pathVersionPattern.MatchString(segment) {
+			return segment
+		}
+	}
+	return ""
+}
+
+// versionName formats an API version for tool names, e.g. 2.1.0 becomes v2_1_0
+func versionName(version string) string {
+	if version == "" {
+		return ""
+	}
+	if version[0] >= '0' && version[0] <= '9' {
+		version = "v" + version
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, version)
+}
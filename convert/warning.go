@@ -0,0 +1,72 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// unsupportedSchemaKeywords are JSON Schema keywords dropped from the generated schemas
+var unsupportedSchemaKeywords = []string{
+	"dependentSchemas",
+	"dependentRequired",
+	"prefixItems",
+	"contains",
+	"unevaluatedProperties",
+	"unevaluatedItems",
+	"propertyNames",
+}
+
+// ConversionWarning describes a feature of an operation that was lost during conversion
+type ConversionWarning struct {
+	Path    string
+	Method  string
+	Feature string
+	// Message describes what was lost, an unsupported schema feature when empty
+	Message string
+}
+
+func (w ConversionWarning) String() string {
+	if w.Message != "" {
+		return fmt.Sprintf("%s %s: %s", strings.ToUpper(w.Method), w.Path, w.Message)
+	}
+	return fmt.Sprintf("%s %s: unsupported schema feature %q was ignored", strings.ToUpper(w.Method), w.Path, w.Feature)
+}
+
+// Warnings returns the warnings emitted by the last conversion
+func (c *Converter) Warnings() []ConversionWarning {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.warnings
+}
+
+// warnUnsupported records the unsupported keywords of a schema for the operation being converted
+func (c *Converter) warnUnsupported(schema *openapi3.Schema) {
+	for _, keyword := range unsupportedSchemaKeywords {
+		if _, ok := schema.Extensions[keyword]; !ok {
+			continue
+		}
+		c.warn(ConversionWarning{
+			Path:    c.current.Path,
+			Method:  c.current.Method,
+			Feature: keyword,
+		})
+	}
+}
+
+// warn records a warning unless it was already emitted
+func (c *Converter) warn(warning ConversionWarning) {
+	if !containsWarning(c.warnings, warning) {
+		c.warnings = append(c.warnings, warning)
+	}
+}
+
+func containsWarning(warnings []ConversionWarning, warning ConversionWarning) bool {
+	for _, w := range warnings {
+		if w == warning {
+			return true
+		}
+	}
+	return false
+}
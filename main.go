@@ -2,24 +2,193 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/zijiren233/openapi-mcp/convert"
 )
 
 var (
-	sse  string
-	file string
-	v2   bool
+	sse            string
+	file           string
+	v2             bool
+	allowMethods   string
+	operations     stringSlice
+	pretty         bool
+	headers        stringSlice
+	queries        stringSlice
+	language       string
+	basePath       string
+	timeout        time.Duration
+	baseURL        string
+	logRequests    bool
+	bodyFileRoot   string
+	dumpSchema     string
+	exportFile     string
+	watch          bool
+	flattenAllOf   bool
+	tokenFile      string
+	maxSchemaDepth int
+	versionInName  bool
 )
 
+// watchInterval is how often the watched file is checked for changes
+const watchInterval = time.Second
+
+// stringSlice is a flag that can be repeated
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// parseKeyValues parses key=value pairs into a map
+func parseKeyValues(values []string) (map[string]string, error) {
+	result := make(map[string]string, len(values))
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", value)
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return result, nil
+}
+
+// printToolSchema prints the description and input schema of the named tool
+func printToolSchema(tools []mcp.Tool, name string) error {
+	for _, tool := range tools {
+		if tool.Name != name {
+			continue
+		}
+		data, err := json.MarshalIndent(tool, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return fmt.Errorf("tool %q not found", name)
+}
+
+// isURL reports whether the openapi document is fetched over http
+func isURL(file string) bool {
+	return strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://")
+}
+
+// isBundle reports whether the openapi document is a directory or zip archive of files
+func isBundle(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && (info.IsDir() || strings.HasSuffix(file, ".zip"))
+}
+
+// parseFile parses the openapi document selected by the flags
+func parseFile() (*convert.Parser, error) {
+	parser := convert.NewParser()
+	var err error
+	if isURL(file) {
+		err = parser.ParseURL(file)
+	} else if isBundle(file) {
+		err = parser.ParseBundle(file)
+	} else if v2 {
+		err = parser.ParseFileV2(file)
+	} else {
+		err = parser.ParseFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// checkWatchable reports why the openapi document can't be watched, only single local files can
+func checkWatchable(file string) error {
+	switch {
+	case isURL(file):
+		return fmt.Errorf("%s is a URL, only local files can be watched", file)
+	case isBundle(file):
+		return fmt.Errorf("%s is a bundle, only single files can be watched", file)
+	}
+	_, err := os.Stat(file)
+	return err
+}
+
+// watchFile polls the openapi file and reloads the tools of the converter when it changes,
+// keeping the previous tools when the new document fails to parse or convert
+func watchFile(converter *convert.Converter) {
+	info, err := os.Stat(file)
+	if err != nil {
+		log.Printf("Failed to watch %s: %v", file, err)
+		return
+	}
+	modTime, size := info.ModTime(), info.Size()
+	for range time.Tick(watchInterval) {
+		info, err := os.Stat(file)
+		if err != nil || (info.ModTime().Equal(modTime) && info.Size() == size) {
+			continue
+		}
+		modTime, size = info.ModTime(), info.Size()
+
+		parser, err := parseFile()
+		if err != nil {
+			log.Printf("Failed to reload OpenAPI document, keeping the previous tools: %v", err)
+			continue
+		}
+		if err := converter.Reload(parser); err != nil {
+			log.Printf("Failed to reload OpenAPI document, keeping the previous tools: %v", err)
+			continue
+		}
+		for _, warning := range converter.Warnings() {
+			log.Printf("Warning: %s", warning)
+		}
+		log.Printf("Reloaded %s: %s", file, converter.Stats())
+	}
+}
+
+// exportTools writes the names, descriptions and input schemas of all tools to a file
+func exportTools(tools []mcp.Tool, path string) error {
+	data, err := json.MarshalIndent(tools, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
 func init() {
 	flag.StringVar(&sse, "sse", "", "it will use sse protocol, example: :3000")
-	flag.StringVar(&file, "file", "", "openapi file path")
+	flag.StringVar(&file, "file", "", "openapi file path or url, a directory or zip archive is loaded as a multi-file bundle")
 	flag.BoolVar(&v2, "v2", false, "openapi v2 version")
+	flag.Var(&operations, "operation", "only convert the operation with this operation id, can be repeated")
+	flag.StringVar(&allowMethods, "allow-methods", "", "only convert operations with these http methods, example: get,post")
+	flag.BoolVar(&pretty, "pretty", false, "indent json request bodies")
+	flag.Var(&headers, "header", "default header sent with every request, example: X-Api-Version=2, can be repeated")
+	flag.Var(&queries, "query", "default query parameter sent with every request, example: api_version=2, can be repeated")
+	flag.StringVar(&basePath, "base-path", "", "prefix prepended to every operation path, example: /api")
+	flag.StringVar(&language, "accept-language", "", "default Accept-Language header, example: en-US")
+	flag.StringVar(&baseURL, "base-url", "", "base url resolving relative server urls, defaults to the openapi url")
+	flag.BoolVar(&logRequests, "log-requests", false, "log upstream requests with credentials and password fields redacted")
+	flag.StringVar(&bodyFileRoot, "body-file-root", "", "directory local request body files may be streamed from")
+	flag.StringVar(&exportFile, "export", "", "write all generated tools to this json file and exit, example: tools.json")
+	flag.StringVar(&dumpSchema, "dump-schema", "", "print the description and input schema of the named tool and exit")
+	flag.DurationVar(&timeout, "timeout", 0, "timeout of each upstream request, example: 30s")
+	flag.BoolVar(&flattenAllOf, "flatten-allof", false, "merge allOf schemas into a single object instead of listing the branches")
+	flag.StringVar(&tokenFile, "auth-token-file", "", "file holding a bearer token sent when no credentials are given, re-read when rotated")
+	flag.BoolVar(&versionInName, "version-in-name", false, "prefix tool names with the api version of the path or document, example: v2_listThings")
+	flag.IntVar(&maxSchemaDepth, "max-schema-depth", 0, "truncate schemas nested deeper than this, required for recursive schemas, 0 means unbounded")
+	flag.BoolVar(&watch, "watch", false, "reload the tools when the local openapi file changes, checked every second")
 }
 
 func main() {
@@ -28,22 +197,75 @@ func main() {
 	if file == "" {
 		log.Fatal("Not provied openapi file")
 	}
-
-	parser := convert.NewParser()
-	var err error
-	if v2 {
-		err = parser.ParseFileV2(file)
-	} else {
-		err = parser.ParseFile(file)
+	if watch {
+		if err := checkWatchable(file); err != nil {
+			log.Fatalf("Can't watch the openapi file: %v", err)
+		}
 	}
+
+	parser, err := parseFile()
 	if err != nil {
 		log.Fatalf("Failed to parse OpenAPI document: %v", err)
 	}
-	converter := convert.NewConverter(parser, convert.Options{})
+	defaultHeaders, err := parseKeyValues(headers)
+	if err != nil {
+		log.Fatalf("Invalid header: %v", err)
+	}
+	defaultQuery, err := parseKeyValues(queries)
+	if err != nil {
+		log.Fatalf("Invalid query: %v", err)
+	}
+	options := convert.Options{
+		PrettyBody:      pretty,
+		DefaultHeaders:  defaultHeaders,
+		DefaultQuery:    defaultQuery,
+		AcceptLanguage:  language,
+		PathPrefix:      basePath,
+		RequestTimeout:  timeout,
+		BaseURL:         baseURL,
+		LogRequests:     logRequests,
+		BodyFileRoot:    bodyFileRoot,
+		ToolListChanged: watch,
+		FlattenAllOf:    flattenAllOf,
+		AuthTokenFile:   tokenFile,
+		MaxSchemaDepth:  maxSchemaDepth,
+		VersionInName:   versionInName,
+	}
+	if allowMethods != "" {
+		for _, method := range strings.Split(allowMethods, ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				options.AllowMethods = append(options.AllowMethods, strings.ToUpper(method))
+			}
+		}
+	}
+	options.IncludeOperationIDs = operations
+	converter := convert.NewConverter(parser, options)
 	s, err := converter.Convert()
 	if err != nil {
 		log.Fatalf("Failed to convert OpenAPI to MCP: %v", err)
 	}
+	for _, warning := range converter.Warnings() {
+		log.Printf("Warning: %s", warning)
+	}
+	log.Printf("Conversion finished: %s", converter.Stats())
+
+	if exportFile != "" {
+		if err := exportTools(converter.Tools(), exportFile); err != nil {
+			log.Fatalf("Failed to export tools: %v", err)
+		}
+		return
+	}
+
+	if dumpSchema != "" {
+		if err := printToolSchema(converter.Tools(), dumpSchema); err != nil {
+			log.Fatalf("Failed to dump schema: %v", err)
+		}
+		return
+	}
+
+	if watch {
+		go watchFile(converter)
+	}
 
 	if sse != "" {
 		err = server.NewSSEServer(s).Start(sse)
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zijiren233/openapi-mcp/convert"
+)
+
+const exportSpec = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    key: {type: apiKey, in: header, name: X-Api-Key}
+    other: {type: apiKey, in: query, name: key}
+  schemas:
+    Money:
+      title: Money
+      type: object
+      properties: {amount: {type: number}, currency: {type: string}}
+paths:
+  /items/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: string}}
+    get:
+      operationId: getItem
+      security: [{bearer: [], key: [], other: []}, {key: []}]
+      parameters:
+        - {name: a, in: query, required: true, schema: {type: string}}
+        - {name: b, in: header, required: true, schema: {type: string}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [z, y, x]
+                properties: {z: {type: string}, y: {type: string}, x: {type: string}}
+            application/xml:
+              schema: {type: string}
+            text/plain:
+              schema: {type: string}
+        "201": {description: created}
+        "404": {description: missing}
+        "500": {description: failed}
+        default: {description: error}
+    post:
+      operationId: createItem
+      security: [{key: [], other: []}]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [c, b, a]
+              properties:
+                c: {type: string}
+                b: {type: integer}
+                a: {type: boolean}
+                price: {$ref: "#/components/schemas/Money"}
+                cost: {$ref: "#/components/schemas/Money"}
+      responses:
+        "200": {description: ok}
+        "400": {description: bad}
+`
+
+// export converts the spec and exports its tools, returning the written bytes
+func export(t *testing.T, spec []byte, path string) []byte {
+	t.Helper()
+	parser := convert.NewParser()
+	if err := parser.Parse(spec); err != nil {
+		t.Fatal(err)
+	}
+	converter := convert.NewConverter(parser, convert.Options{})
+	if _, err := converter.Convert(); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportTools(converter.Tools(), path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestExportToolsIsStable(t *testing.T) {
+	dir := t.TempDir()
+	first := export(t, []byte(exportSpec), filepath.Join(dir, "first.json"))
+	// Map iteration order changes between runs, export repeatedly to catch order dependence
+	for range 20 {
+		if next := export(t, []byte(exportSpec), filepath.Join(dir, "next.json")); !bytes.Equal(first, next) {
+			t.Fatalf("exports differ:\n%s\n---\n%s", first, next)
+		}
+	}
+}